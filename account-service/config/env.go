@@ -1,17 +1,37 @@
 package config
 
 import (
+	"account-service/tracing"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const (
+	StorageDriverMongo    = "mongo"
+	StorageDriverPostgres = "postgres"
+	StorageDriverMemory   = "memory"
+)
+
 type Value struct {
+	Storage       Storage
 	NoSqlDatabase NoSqlDatabase
+	SqlDatabase   SqlDatabase
 	Auth          Auth
+	Purge         Purge
+	Migration     Migration
 	Log           Log
 	Server        Server
+	Interceptor   Interceptor
+	Trace         tracing.Config
+}
+
+type Storage struct {
+	Driver string
 }
 
 type NoSqlDatabase struct {
@@ -20,17 +40,52 @@ type NoSqlDatabase struct {
 	MaxIdleConn string
 }
 
+type SqlDatabase struct {
+	DSN         string
+	MaxIdleTime string
+	MaxIdleConn string
+	MaxOpenConn string
+}
+
 type Auth struct {
-	SecretKey string
+	SecretKey       string
+	AdminEmail      string
+	RefreshTokenTTL time.Duration
+}
+
+type Purge struct {
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+type Migration struct {
+	OnBoot bool
 }
 
 type Server struct {
-	Base string
-	Port int
+	Base                string
+	Port                int
+	AdminPort           int
+	Reflection          bool
+	ShutdownTimeout     time.Duration
+	HealthCheckInterval time.Duration
+}
+
+// Interceptor selects the optional interceptors of each chain of the grpc server
+type Interceptor struct {
+	Unary  InterceptorChain
+	Stream InterceptorChain
+}
+
+type InterceptorChain struct {
+	Log      bool
+	Recovery bool
+	Metrics  bool
 }
 
 type Log struct {
-	Level string
+	Level       string
+	RedactEmail bool
 }
 
 func InitEnv() (*Value, error) {
@@ -39,26 +94,185 @@ func InitEnv() (*Value, error) {
 		return nil, err
 	}
 
+	storageDriver := os.Getenv("STORAGE_DRIVER")
+	switch storageDriver {
+	case "":
+		storageDriver = StorageDriverMongo
+	case StorageDriverMongo, StorageDriverPostgres, StorageDriverMemory:
+	default:
+		return nil, fmt.Errorf("STORAGE_DRIVER must be %v, %v or %v", StorageDriverMongo, StorageDriverPostgres, StorageDriverMemory)
+	}
+
 	port, err := strconv.Atoi(os.Getenv("SERVER_PORT"))
 	if err != nil {
 		return nil, err
 	}
 
+	refreshTokenTTL := 30 * 24 * time.Hour
+	if os.Getenv("AUTH_REFRESH_TOKEN_TTL") != "" {
+		refreshTokenTTL, err = time.ParseDuration(os.Getenv("AUTH_REFRESH_TOKEN_TTL"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	purgeRetention := 30 * 24 * time.Hour
+	if os.Getenv("PURGE_RETENTION") != "" {
+		purgeRetention, err = time.ParseDuration(os.Getenv("PURGE_RETENTION"))
+		if err != nil {
+			return nil, err
+		}
+		if purgeRetention <= 0 {
+			return nil, fmt.Errorf("PURGE_RETENTION must be positive, got %v", purgeRetention)
+		}
+	}
+
+	purgeInterval := time.Hour
+	if os.Getenv("PURGE_INTERVAL") != "" {
+		purgeInterval, err = time.ParseDuration(os.Getenv("PURGE_INTERVAL"))
+		if err != nil {
+			return nil, err
+		}
+		if purgeInterval <= 0 {
+			return nil, fmt.Errorf("PURGE_INTERVAL must be positive, got %v", purgeInterval)
+		}
+	}
+
+	migrateOnBoot := false
+	if os.Getenv("MIGRATE_ON_BOOT") != "" {
+		migrateOnBoot, err = strconv.ParseBool(os.Getenv("MIGRATE_ON_BOOT"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// metrics are served on their own listener, which is off unless a port is set
+	adminPort := 0
+	if os.Getenv("SERVER_ADMIN_PORT") != "" {
+		adminPort, err = strconv.Atoi(os.Getenv("SERVER_ADMIN_PORT"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reflection := false
+	if os.Getenv("SERVER_REFLECTION") != "" {
+		reflection, err = strconv.ParseBool(os.Getenv("SERVER_REFLECTION"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// in flight rpcs get this long to finish once SIGTERM is received
+	shutdownTimeout := 15 * time.Second
+	if os.Getenv("SERVER_SHUTDOWN_TIMEOUT") != "" {
+		shutdownTimeout, err = time.ParseDuration(os.Getenv("SERVER_SHUTDOWN_TIMEOUT"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	healthCheckInterval := 10 * time.Second
+	if os.Getenv("SERVER_HEALTH_CHECK_INTERVAL") != "" {
+		healthCheckInterval, err = time.ParseDuration(os.Getenv("SERVER_HEALTH_CHECK_INTERVAL"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// secrets are always masked in logs, emails only when asked to
+	redactEmail := false
+	if os.Getenv("LOG_REDACT_EMAIL") != "" {
+		redactEmail, err = strconv.ParseBool(os.Getenv("LOG_REDACT_EMAIL"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	unaryInterceptors, err := interceptorChain("GRPC_UNARY_INTERCEPTORS")
+	if err != nil {
+		return nil, err
+	}
+
+	streamInterceptors, err := interceptorChain("GRPC_STREAM_INTERCEPTORS")
+	if err != nil {
+		return nil, err
+	}
+
+	trace, err := tracing.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Value{
+		Storage: Storage{
+			Driver: storageDriver,
+		},
 		NoSqlDatabase: NoSqlDatabase{
 			DSN:         os.Getenv("MONGO_DSN"),
 			MaxIdleTime: os.Getenv("MONGO_MAX_IDLE_TIME"),
 			MaxIdleConn: os.Getenv("MONGO_MAX_IDLE_CONN"),
 		},
+		SqlDatabase: SqlDatabase{
+			DSN:         os.Getenv("POSTGRES_DSN"),
+			MaxIdleTime: os.Getenv("POSTGRES_MAX_IDLE_TIME"),
+			MaxIdleConn: os.Getenv("POSTGRES_MAX_IDLE_CONN"),
+			MaxOpenConn: os.Getenv("POSTGRES_MAX_OPEN_CONN"),
+		},
 		Auth: Auth{
-			SecretKey: os.Getenv("AUTH_SECRETKEY"),
+			SecretKey:       os.Getenv("AUTH_SECRETKEY"),
+			AdminEmail:      os.Getenv("AUTH_ADMIN_EMAIL"),
+			RefreshTokenTTL: refreshTokenTTL,
+		},
+		Purge: Purge{
+			Retention: purgeRetention,
+			Interval:  purgeInterval,
+		},
+		Migration: Migration{
+			OnBoot: migrateOnBoot,
 		},
 		Log: Log{
-			Level: os.Getenv("LOG_LEVEL"),
+			Level:       os.Getenv("LOG_LEVEL"),
+			RedactEmail: redactEmail,
 		},
 		Server: Server{
-			Base: os.Getenv("SERVER_BASE"),
-			Port: port,
+			Base:                os.Getenv("SERVER_BASE"),
+			Port:                port,
+			AdminPort:           adminPort,
+			Reflection:          reflection,
+			ShutdownTimeout:     shutdownTimeout,
+			HealthCheckInterval: healthCheckInterval,
 		},
+		Interceptor: Interceptor{
+			Unary:  unaryInterceptors,
+			Stream: streamInterceptors,
+		},
+		Trace: trace,
 	}, nil
 }
+
+// interceptorChain reads a comma separated list of log, recovery and metrics from env,
+// every interceptor is used when env is not set and none when it is empty
+func interceptorChain(env string) (InterceptorChain, error) {
+	value, ok := os.LookupEnv(env)
+	if !ok {
+		return InterceptorChain{Log: true, Recovery: true, Metrics: true}, nil
+	}
+
+	chain := InterceptorChain{}
+	for _, name := range strings.Split(value, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "log":
+			chain.Log = true
+		case "recovery":
+			chain.Recovery = true
+		case "metrics":
+			chain.Metrics = true
+		default:
+			return chain, fmt.Errorf("%v has unknown interceptor %q, expected log, recovery or metrics", env, name)
+		}
+	}
+
+	return chain, nil
+}
@@ -5,9 +5,28 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+var (
+	mongoPoolConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "account_mongo_pool_connections",
+		Help: "Open connections of the mongo pool by server address.",
+	}, []string{"address"})
+	mongoPoolInUse = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "account_mongo_pool_connections_in_use",
+		Help: "Connections checked out of the mongo pool by server address.",
+	}, []string{"address"})
+	mongoPoolCheckoutFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "account_mongo_pool_checkout_failures_total",
+		Help: "Failed attempts to check a connection out of the mongo pool by server address.",
+	}, []string{"address"})
 )
 
 func InitNoSql(cfg *Value) (*mongo.Client, error) {
@@ -26,7 +45,9 @@ func InitNoSql(cfg *Value) (*mongo.Client, error) {
 		SetServerAPIOptions(options.ServerAPI(options.ServerAPIVersion1)).
 		SetMaxPoolSize(maxPoolSize).
 		SetMinPoolSize(1).
-		SetMaxConnIdleTime(duration)
+		SetMaxConnIdleTime(duration).
+		SetMonitor(otelmongo.NewMonitor()).
+		SetPoolMonitor(&event.PoolMonitor{Event: observePool})
 
 	client, err := mongo.Connect(context.Background(), opts)
 	if err != nil {
@@ -42,3 +63,19 @@ func InitNoSql(cfg *Value) (*mongo.Client, error) {
 
 	return client, nil
 }
+
+// observePool keeps the mongo pool metrics up to date with events of the driver
+func observePool(e *event.PoolEvent) {
+	switch e.Type {
+	case event.ConnectionCreated:
+		mongoPoolConnections.WithLabelValues(e.Address).Inc()
+	case event.ConnectionClosed:
+		mongoPoolConnections.WithLabelValues(e.Address).Dec()
+	case event.GetSucceeded:
+		mongoPoolInUse.WithLabelValues(e.Address).Inc()
+	case event.ConnectionReturned:
+		mongoPoolInUse.WithLabelValues(e.Address).Dec()
+	case event.GetFailed:
+		mongoPoolCheckoutFailures.WithLabelValues(e.Address).Inc()
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func InitSql(cfg *Value) (*gorm.DB, error) {
+	maxIdleConn, err := strconv.Atoi(cfg.SqlDatabase.MaxIdleConn)
+	if err != nil {
+		return nil, err
+	}
+
+	maxOpenConn, err := strconv.Atoi(cfg.SqlDatabase.MaxOpenConn)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := time.ParseDuration(cfg.SqlDatabase.MaxIdleTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// errors are translated so that domain can tell duplicated keys apart
+	db, err := gorm.Open(postgres.Open(cfg.SqlDatabase.DSN), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxIdleConns(maxIdleConn)
+	sqlDB.SetMaxOpenConns(maxOpenConn)
+	sqlDB.SetConnMaxIdleTime(duration)
+
+	ctx, cleanup := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cleanup()
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
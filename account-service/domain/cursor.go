@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"account-service/errors"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// pageCursor points right after the last item of a page, it is opaque to clients
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	Id    string `json:"id"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor parses cursor, it must have been issued for the same sort order
+func decodeCursor(token, sort string) (pageCursor, error) {
+	cursor := pageCursor{}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, errors.NewFieldError("Cursor", "malformed cursor")
+	}
+
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return cursor, errors.NewFieldError("Cursor", "malformed cursor")
+	}
+
+	if cursor.Sort != sort {
+		return cursor, errors.NewFieldError("Cursor", "cursor was issued for another sort order")
+	}
+
+	return cursor, nil
+}
@@ -9,12 +9,25 @@ import (
 )
 
 type Domains struct {
-	User UserInterface
+	Storage      StorageInterface
+	User         UserInterface
+	UserHistory  UserHistoryInterface
+	RefreshToken RefreshTokenInterface
+	RevokedToken RevokedTokenInterface
 }
 
 func Init(db *mongo.Client, logger *logrus.Logger) *Domains {
+	return initDatabase(logger, db, db.Database(Database))
+}
+
+// initDatabase creates domains storing data in database, which tests point at a throwaway one
+func initDatabase(logger *logrus.Logger, client *mongo.Client, database *mongo.Database) *Domains {
 	return &Domains{
-		User: initUser(logger, db.Database("account-service").Collection("user")),
+		Storage:      &mongoStorage{client: client},
+		User:         initUser(logger, database.Collection("user")),
+		UserHistory:  initUserHistory(logger, database.Collection("user_history")),
+		RefreshToken: initRefreshToken(logger, database.Collection("refresh_token")),
+		RevokedToken: initRevokedToken(logger, database.Collection("revoked_token")),
 	}
 }
 
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type userHistory struct {
+	logger     *logrus.Logger
+	collection *mongo.Collection
+}
+
+type UserHistoryInterface interface {
+	Create(ctx context.Context, change entity.UserChange) error
+	List(ctx context.Context, query entity.UserHistoryQuery) (entity.UserHistory, error)
+}
+
+// initUserHistory creates user history domain
+func initUserHistory(logger *logrus.Logger, db *mongo.Collection) UserHistoryInterface {
+	return &userHistory{
+		logger:     logger,
+		collection: db,
+	}
+}
+
+// Create records a change made to user
+func (h *userHistory) Create(ctx context.Context, change entity.UserChange) error {
+	if _, err := h.collection.InsertOne(ctx, change); err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// List returns a page of changes made to user, newest first
+func (h *userHistory) List(ctx context.Context, query entity.UserHistoryQuery) (entity.UserHistory, error) {
+	history := entity.UserHistory{Changes: []entity.UserChange{}}
+	filter := bson.M{"user_id": query.UserId}
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor, historySort)
+		if err != nil {
+			return history, err
+		}
+
+		lastId, err := primitive.ObjectIDFromHex(cursor.Id)
+		if err != nil {
+			return history, errors.NewFieldError("Cursor", "malformed cursor")
+		}
+		filter["_id"] = bson.M{"$lt": lastId}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(query.Limit) + 1)
+
+	cursor, err := h.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return history, errorAlias(err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &history.Changes); err != nil {
+		return history, errorAlias(err)
+	}
+
+	if len(history.Changes) > query.Limit {
+		history.Changes = history.Changes[:query.Limit]
+		last := history.Changes[len(history.Changes)-1]
+		history.NextCursor = encodeCursor(pageCursor{Sort: historySort, Id: last.Id.Hex()})
+	}
+
+	return history, nil
+}
+
+// history is always listed newest first
+const historySort = "-created"
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryUserHistory keeps user history in memory, for running without a database
+type memoryUserHistory struct {
+	logger  *logrus.Logger
+	mu      sync.RWMutex
+	changes []entity.UserChange
+}
+
+// initMemoryUserHistory creates in memory user history domain
+func initMemoryUserHistory(logger *logrus.Logger) UserHistoryInterface {
+	return &memoryUserHistory{
+		logger: logger,
+	}
+}
+
+// Create records a change made to user
+func (h *memoryUserHistory) Create(ctx context.Context, change entity.UserChange) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if change.Id.IsZero() {
+		change.Id = primitive.NewObjectID()
+	}
+	h.changes = append(h.changes, change)
+
+	return nil
+}
+
+// List returns a page of changes made to user, newest first
+func (h *memoryUserHistory) List(ctx context.Context, query entity.UserHistoryQuery) (entity.UserHistory, error) {
+	history := entity.UserHistory{Changes: []entity.UserChange{}}
+
+	var lastId *primitive.ObjectID
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor, historySort)
+		if err != nil {
+			return history, err
+		}
+
+		id, err := primitive.ObjectIDFromHex(cursor.Id)
+		if err != nil {
+			return history, errors.NewFieldError("Cursor", "malformed cursor")
+		}
+		lastId = &id
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	// changes are appended in id order, so walking backwards is newest first
+	for i := len(h.changes) - 1; i >= 0; i-- {
+		change := h.changes[i]
+		if change.UserId != query.UserId || (lastId != nil && compareIds(change.Id, *lastId) >= 0) {
+			continue
+		}
+
+		if len(history.Changes) == query.Limit {
+			last := history.Changes[len(history.Changes)-1]
+			history.NextCursor = encodeCursor(pageCursor{Sort: historySort, Id: last.Id.Hex()})
+			break
+		}
+		history.Changes = append(history.Changes, change)
+	}
+
+	return history, nil
+}
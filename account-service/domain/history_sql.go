@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+type sqlUserHistory struct {
+	logger *logrus.Logger
+	db     *gorm.DB
+}
+
+// initSqlUserHistory creates postgres user history domain
+func initSqlUserHistory(logger *logrus.Logger, db *gorm.DB) UserHistoryInterface {
+	return &sqlUserHistory{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// Create records a change made to user
+func (h *sqlUserHistory) Create(ctx context.Context, change entity.UserChange) error {
+	row := newUserChangeRow(change)
+	if err := h.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// List returns a page of changes made to user, newest first
+func (h *sqlUserHistory) List(ctx context.Context, query entity.UserHistoryQuery) (entity.UserHistory, error) {
+	history := entity.UserHistory{Changes: []entity.UserChange{}}
+	tx := h.db.WithContext(ctx).Where("user_id = ?", query.UserId.Hex())
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor, historySort)
+		if err != nil {
+			return history, err
+		}
+
+		if _, err := primitive.ObjectIDFromHex(cursor.Id); err != nil {
+			return history, errors.NewFieldError("Cursor", "malformed cursor")
+		}
+		tx = tx.Where("id < ?", cursor.Id)
+	}
+
+	rows := []userChangeRow{}
+	if err := tx.Order("id DESC").Limit(query.Limit + 1).Find(&rows).Error; err != nil {
+		return history, errorAlias(err)
+	}
+
+	for _, row := range rows {
+		history.Changes = append(history.Changes, row.entity())
+	}
+
+	if len(history.Changes) > query.Limit {
+		history.Changes = history.Changes[:query.Limit]
+		last := history.Changes[len(history.Changes)-1]
+		history.NextCursor = encodeCursor(pageCursor{Sort: historySort, Id: last.Id.Hex()})
+	}
+
+	return history, nil
+}
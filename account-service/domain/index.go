@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Database holds every collection of account-service
+const Database = "account-service"
+
+// emailCollation compares emails ignoring case, queries by email must use it to hit the unique index
+var emailCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// indexSpec is an index the domain layer relies on
+type indexSpec struct {
+	collection string
+	name       string
+	keys       bson.D
+	unique     bool
+	collation  *options.Collation
+	// partial limits the index to documents matching it
+	partial bson.M
+	// expireAfter makes a ttl index, documents are removed this many seconds after the indexed time
+	expireAfter *int32
+}
+
+// expireAt removes documents as soon as the indexed time has passed
+var expireAt = int32(0)
+
+var indexSpecs = []indexSpec{
+	{collection: "user", name: "email_unique", keys: bson.D{{Key: "email", Value: 1}}, unique: true, collation: emailCollation, partial: notDeleted},
+	{collection: "user_history", name: "user_id_newest", keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: -1}}},
+	{collection: "revoked_token", name: "jti", keys: bson.D{{Key: "jti", Value: 1}}},
+	{collection: "revoked_token", name: "user_id", keys: bson.D{{Key: "user_id", Value: 1}}},
+	{collection: "revoked_token", name: "expires_at_ttl", keys: bson.D{{Key: "expires_at", Value: 1}}, expireAfter: &expireAt},
+}
+
+// existingIndex is an index as listed by mongo
+type existingIndex struct {
+	Name      string `bson:"name"`
+	Key       bson.D `bson:"key"`
+	Unique    bool   `bson:"unique"`
+	Collation *struct {
+		Locale   string `bson:"locale"`
+		Strength int    `bson:"strength"`
+	} `bson:"collation"`
+	ExpireAfterSeconds      *int64 `bson:"expireAfterSeconds"`
+	PartialFilterExpression bson.M `bson:"partialFilterExpression"`
+}
+
+// EnsureIndexes creates missing indexes and reports drift, an index that exists
+// with other keys or options is reported but left alone since fixing it needs a rebuild
+func EnsureIndexes(ctx context.Context, db *mongo.Client, logger *logrus.Logger) error {
+	return ensureIndexes(ctx, db.Database(Database), logger)
+}
+
+func ensureIndexes(ctx context.Context, database *mongo.Database, logger *logrus.Logger) error {
+	for _, spec := range indexSpecs {
+		collection := database.Collection(spec.collection)
+
+		existing, err := listIndexes(ctx, collection)
+		if err != nil {
+			return fmt.Errorf("failed to list indexes of %v: %w", spec.collection, err)
+		}
+
+		if index, ok := existing[spec.name]; ok {
+			if drift := spec.drift(index); drift != "" {
+				logger.Warnf("index %v on %v drifted: %v, drop it to let it be recreated", spec.name, spec.collection, drift)
+			}
+			continue
+		}
+
+		opts := options.Index().SetName(spec.name)
+		if spec.unique {
+			opts.SetUnique(true)
+		}
+		if spec.collation != nil {
+			opts.SetCollation(spec.collation)
+		}
+		if spec.expireAfter != nil {
+			opts.SetExpireAfterSeconds(*spec.expireAfter)
+		}
+		if spec.partial != nil {
+			opts.SetPartialFilterExpression(spec.partial)
+		}
+
+		if _, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: spec.keys, Options: opts}); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return fmt.Errorf("failed to create index %v on %v, stored documents violate it: %w", spec.name, spec.collection, err)
+			}
+			return fmt.Errorf("failed to create index %v on %v: %w", spec.name, spec.collection, err)
+		}
+
+		logger.Infof("created index %v on %v", spec.name, spec.collection)
+	}
+
+	return nil
+}
+
+func listIndexes(ctx context.Context, collection *mongo.Collection) (map[string]existingIndex, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	indexes := []existingIndex{}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, err
+	}
+
+	byName := map[string]existingIndex{}
+	for _, index := range indexes {
+		byName[index.Name] = index
+	}
+
+	return byName, nil
+}
+
+// drift describes how index differs from spec, empty when it matches
+func (spec indexSpec) drift(index existingIndex) string {
+	if len(index.Key) != len(spec.keys) {
+		return fmt.Sprintf("keys are %v instead of %v", index.Key, spec.keys)
+	}
+	for i, key := range spec.keys {
+		// mongo may return key directions as int32, int64 or double
+		if index.Key[i].Key != key.Key || fmt.Sprint(index.Key[i].Value) != fmt.Sprint(key.Value) {
+			return fmt.Sprintf("keys are %v instead of %v", index.Key, spec.keys)
+		}
+	}
+
+	if index.Unique != spec.unique {
+		return fmt.Sprintf("unique is %v instead of %v", index.Unique, spec.unique)
+	}
+
+	switch {
+	case spec.collation == nil && index.Collation != nil:
+		return "it has a collation"
+	case spec.collation != nil && index.Collation == nil:
+		return "it has no collation"
+	case spec.collation != nil && (index.Collation.Locale != spec.collation.Locale || index.Collation.Strength != spec.collation.Strength):
+		return fmt.Sprintf("collation is %v/%v instead of %v/%v", index.Collation.Locale, index.Collation.Strength, spec.collation.Locale, spec.collation.Strength)
+	}
+
+	switch {
+	case spec.expireAfter == nil && index.ExpireAfterSeconds != nil:
+		return "it expires documents"
+	case spec.expireAfter != nil && index.ExpireAfterSeconds == nil:
+		return "it does not expire documents"
+	case spec.expireAfter != nil && *index.ExpireAfterSeconds != int64(*spec.expireAfter):
+		return fmt.Sprintf("documents expire after %v seconds instead of %v", *index.ExpireAfterSeconds, *spec.expireAfter)
+	}
+
+	// maps print with sorted keys, so equal filters print the same
+	if fmt.Sprint(index.PartialFilterExpression) != fmt.Sprint(spec.partial) {
+		return fmt.Sprintf("partial filter is %v instead of %v", index.PartialFilterExpression, spec.partial)
+	}
+
+	return ""
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// InitMemory creates domains keeping everything in memory, data is lost on restart
+// so it is only meant for local development and tests
+func InitMemory(logger *logrus.Logger) *Domains {
+	return &Domains{
+		Storage:      memoryStorage{},
+		User:         initMemoryUser(logger),
+		UserHistory:  initMemoryUserHistory(logger),
+		RefreshToken: initMemoryRefreshToken(logger),
+		RevokedToken: initMemoryRevokedToken(logger),
+	}
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type revokedToken struct {
+	logger     *logrus.Logger
+	collection *mongo.Collection
+}
+
+type RevokedTokenInterface interface {
+	IsRevoked(ctx context.Context, token entity.RevokedToken) (bool, error)
+	Create(ctx context.Context, token entity.RevokedToken) error
+	RevokeUser(ctx context.Context, userId primitive.ObjectID) error
+	PurgeExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// initRevokedToken creates revoked token domain
+func initRevokedToken(logger *logrus.Logger, db *mongo.Collection) RevokedTokenInterface {
+	return &revokedToken{
+		logger:     logger,
+		collection: db,
+	}
+}
+
+// IsRevoked reports whether the token itself or every token of its user issued until it was revoked
+func (r *revokedToken) IsRevoked(ctx context.Context, token entity.RevokedToken) (bool, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"jti": token.Jti},
+		bson.M{"user_id": token.UserId, "jti": bson.M{"$exists": false}, "revoked_at": bson.M{"$gte": token.IssuedAt}},
+	}}
+
+	err := r.collection.FindOne(ctx, filter).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	} else if err != nil {
+		return false, errorAlias(err)
+	}
+
+	return true, nil
+}
+
+// Create adds a single token to revocation list
+func (r *revokedToken) Create(ctx context.Context, token entity.RevokedToken) error {
+	token.RevokedAt = time.Now()
+
+	if _, err := r.collection.InsertOne(ctx, token); err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// RevokeUser revokes every token of user issued until now, iat has whole seconds
+// so a token issued within the same second can not be told apart and is revoked too
+func (r *revokedToken) RevokeUser(ctx context.Context, userId primitive.ObjectID) error {
+	filter := bson.M{"user_id": userId, "jti": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// PurgeExpired is a no-op, the expires_at_ttl index lets mongo remove expired tokens itself
+func (r *revokedToken) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
@@ -0,0 +1,215 @@
+package domain
+
+import (
+	"account-service/entity"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+)
+
+// InitSql creates domains storing data in postgres, ids are kept as hex strings
+// so that they are interchangeable with the object ids of the mongo domains
+func InitSql(db *gorm.DB, logger *logrus.Logger) *Domains {
+	return &Domains{
+		Storage:      &sqlStorage{db: db},
+		User:         initSqlUser(logger, db),
+		UserHistory:  initSqlUserHistory(logger, db),
+		RefreshToken: initSqlRefreshToken(logger, db),
+		RevokedToken: initSqlRevokedToken(logger, db),
+	}
+}
+
+// userRow is user as stored in the users table
+type userRow struct {
+	Id          string `gorm:"primaryKey"`
+	Name        string
+	Email       string
+	Password    string
+	Role        string
+	Version     int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	LastLoginAt *time.Time
+	DeletedAt   *time.Time
+
+	PasswordResetRequired bool
+}
+
+func (userRow) TableName() string {
+	return "users"
+}
+
+func newUserRow(user entity.User) userRow {
+	return userRow{
+		Id:          hexId(user.Id),
+		Name:        user.Name,
+		Email:       user.Email,
+		Password:    user.Password,
+		Role:        user.Role,
+		Version:     user.Version,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		LastLoginAt: nullTime(user.LastLoginAt),
+		DeletedAt:   nullTime(user.DeletedAt),
+
+		PasswordResetRequired: user.PasswordResetRequired,
+	}
+}
+
+func (r userRow) entity() entity.User {
+	return entity.User{
+		Id:          objectId(r.Id),
+		Name:        r.Name,
+		Email:       r.Email,
+		Password:    r.Password,
+		Role:        r.Role,
+		Version:     r.Version,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+		LastLoginAt: timeOf(r.LastLoginAt),
+		DeletedAt:   timeOf(r.DeletedAt),
+
+		PasswordResetRequired: r.PasswordResetRequired,
+	}
+}
+
+// userChangeRow is user change as stored in the user_history table
+type userChangeRow struct {
+	Id        string `gorm:"primaryKey"`
+	UserId    string
+	ActorId   *string
+	Action    string
+	Changes   []entity.FieldChange `gorm:"serializer:json"`
+	CreatedAt time.Time
+}
+
+func (userChangeRow) TableName() string {
+	return "user_history"
+}
+
+func newUserChangeRow(change entity.UserChange) userChangeRow {
+	row := userChangeRow{
+		Id:        hexId(change.Id),
+		UserId:    change.UserId.Hex(),
+		Action:    change.Action,
+		Changes:   change.Changes,
+		CreatedAt: change.CreatedAt,
+	}
+
+	if !change.ActorId.IsZero() {
+		actorId := change.ActorId.Hex()
+		row.ActorId = &actorId
+	}
+
+	return row
+}
+
+func (r userChangeRow) entity() entity.UserChange {
+	change := entity.UserChange{
+		Id:        objectId(r.Id),
+		UserId:    objectId(r.UserId),
+		Action:    r.Action,
+		Changes:   r.Changes,
+		CreatedAt: r.CreatedAt,
+	}
+
+	if r.ActorId != nil {
+		change.ActorId = objectId(*r.ActorId)
+	}
+
+	return change
+}
+
+// refreshTokenRow is refresh token as stored in the refresh_tokens table
+type refreshTokenRow struct {
+	Id        string `gorm:"primaryKey"`
+	UserId    string
+	FamilyId  string
+	Hash      string
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	Revoked   bool
+}
+
+func (refreshTokenRow) TableName() string {
+	return "refresh_tokens"
+}
+
+func newRefreshTokenRow(token entity.RefreshToken) refreshTokenRow {
+	return refreshTokenRow{
+		Id:        hexId(token.Id),
+		UserId:    token.UserId.Hex(),
+		FamilyId:  token.FamilyId.Hex(),
+		Hash:      token.Hash,
+		ExpiresAt: token.ExpiresAt,
+		RotatedAt: nullTime(token.RotatedAt),
+		Revoked:   token.Revoked,
+	}
+}
+
+func (r refreshTokenRow) entity() entity.RefreshToken {
+	return entity.RefreshToken{
+		Id:        objectId(r.Id),
+		UserId:    objectId(r.UserId),
+		FamilyId:  objectId(r.FamilyId),
+		Hash:      r.Hash,
+		ExpiresAt: r.ExpiresAt,
+		RotatedAt: timeOf(r.RotatedAt),
+		Revoked:   r.Revoked,
+	}
+}
+
+// revokedTokenRow is revoked token as stored in the revoked_tokens table,
+// rows without jti revoke every token of the user issued until revoked_at
+type revokedTokenRow struct {
+	Id        string `gorm:"primaryKey"`
+	Jti       *string
+	UserId    string
+	ExpiresAt *time.Time
+	RevokedAt time.Time
+}
+
+func (revokedTokenRow) TableName() string {
+	return "revoked_tokens"
+}
+
+// hexId returns id as hex, generating a new one when it is empty
+func hexId(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return primitive.NewObjectID().Hex()
+	}
+
+	return id.Hex()
+}
+
+func hexIds(ids []primitive.ObjectID) []string {
+	hexes := make([]string, len(ids))
+	for i, id := range ids {
+		hexes[i] = id.Hex()
+	}
+
+	return hexes
+}
+
+func objectId(hex string) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(hex)
+	return id
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+func timeOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"gorm.io/gorm"
+)
+
+// StorageInterface is the connection every domain stores its data through
+type StorageInterface interface {
+	Ping(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+type mongoStorage struct {
+	client *mongo.Client
+}
+
+func (s *mongoStorage) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx, readpref.Primary())
+}
+
+func (s *mongoStorage) Close(ctx context.Context) error {
+	return s.client.Disconnect(ctx)
+}
+
+type sqlStorage struct {
+	db *gorm.DB
+}
+
+func (s *sqlStorage) Ping(ctx context.Context) error {
+	db, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return db.PingContext(ctx)
+}
+
+func (s *sqlStorage) Close(ctx context.Context) error {
+	db, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return db.Close()
+}
+
+// memoryStorage is always reachable and has nothing to close
+type memoryStorage struct{}
+
+func (memoryStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (memoryStorage) Close(ctx context.Context) error {
+	return nil
+}
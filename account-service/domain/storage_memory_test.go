@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, func(t *testing.T) *Domains {
+		return InitMemory(logrus.New())
+	})
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestMongoStorage needs a running mongo, TEST_MONGO_DSN is its connection string
+func TestMongoStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_MONGO_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MONGO_DSN is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(dsn))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	logger := logrus.New()
+	testStorage(t, func(t *testing.T) *Domains {
+		// every test gets its own database, with the indexes the domains rely on
+		database := client.Database("test_" + primitive.NewObjectID().Hex())
+		t.Cleanup(func() { database.Drop(context.Background()) })
+
+		if err := ensureIndexes(context.Background(), database, logger); err != nil {
+			t.Fatalf("ensure indexes: %v", err)
+		}
+
+		return initDatabase(logger, client, database)
+	})
+}
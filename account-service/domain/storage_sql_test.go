@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"account-service/migration"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestPostgresStorage needs a running postgres, TEST_POSTGRES_DSN is its connection string
+func TestPostgresStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	log := logrus.New()
+	testStorage(t, func(t *testing.T) *Domains {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+			TranslateError: true,
+			Logger:         logger.Default.LogMode(logger.Silent),
+		})
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		t.Cleanup(func() { sqlDB.Close() })
+
+		// every test gets its own schema, a single connection keeps the search path on every query
+		schema := "test_" + primitive.NewObjectID().Hex()
+		sqlDB.SetMaxOpenConns(1)
+		if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+			t.Fatalf("create schema: %v", err)
+		}
+		t.Cleanup(func() { db.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+		if err := db.Exec("SET search_path TO " + schema).Error; err != nil {
+			t.Fatalf("set search path: %v", err)
+		}
+
+		if _, err := migration.InitSql(db, log).Up(context.Background()); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+
+		return InitSql(db, log)
+	})
+}
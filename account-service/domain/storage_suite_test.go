@@ -0,0 +1,407 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testStorage runs the same tests against every storage driver, newDomains must return empty storage
+func testStorage(t *testing.T, newDomains func(t *testing.T) *Domains) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, dom *Domains)
+	}{
+		{"create and get", testCreateAndGet},
+		{"unique email", testUniqueEmail},
+		{"get many by email", testGetManyByEmail},
+		{"update", testUpdate},
+		{"version precondition", testVersionPrecondition},
+		{"password reset", testPasswordReset},
+		{"soft delete", testSoftDelete},
+		{"delete many", testDeleteMany},
+		{"restore", testRestore},
+		{"purge", testPurge},
+		{"history", testHistory},
+		{"revocation", testRevocation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newDomains(t))
+		})
+	}
+}
+
+func createUser(t *testing.T, dom *Domains, email string) entity.User {
+	t.Helper()
+
+	user, err := dom.User.Create(context.Background(), entity.User{Name: "name", Email: email, Password: "hash", Role: entity.RoleUser})
+	if err != nil {
+		t.Fatalf("create %v: %v", email, err)
+	}
+
+	return user
+}
+
+func assertErr(t *testing.T, err, want error) {
+	t.Helper()
+
+	if !errors.Is(err, want) {
+		t.Fatalf("got error %v, want %v", err, want)
+	}
+}
+
+func testCreateAndGet(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	created := createUser(t, dom, "user@example.com")
+
+	if created.Id.IsZero() || created.Version != 1 || created.CreatedAt.IsZero() {
+		t.Fatalf("created user has id %v, version %v and created at %v", created.Id, created.Version, created.CreatedAt)
+	}
+
+	byId, err := dom.User.Get(ctx, entity.User{Id: created.Id})
+	if err != nil {
+		t.Fatalf("get by id: %v", err)
+	}
+	if byId.Email != created.Email || byId.Name != created.Name || byId.Role != created.Role {
+		t.Fatalf("got %+v, want %+v", byId, created)
+	}
+
+	byEmail, err := dom.User.Get(ctx, entity.User{Email: "USER@example.com"})
+	if err != nil {
+		t.Fatalf("get by email ignoring case: %v", err)
+	}
+	if byEmail.Id != created.Id {
+		t.Fatalf("got user %v, want %v", byEmail.Id, created.Id)
+	}
+
+	_, err = dom.User.Get(ctx, entity.User{Id: primitive.NewObjectID()})
+	assertErr(t, err, errors.ErrNotFound)
+}
+
+func testUniqueEmail(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	createUser(t, dom, "taken@example.com")
+	other := createUser(t, dom, "other@example.com")
+
+	_, err := dom.User.Create(ctx, entity.User{Name: "name", Email: "Taken@example.com"})
+	assertErr(t, err, errors.ErrDuplicatedKey)
+
+	other.Version = 0
+	other.Email = "taken@example.com"
+	_, err = dom.User.Update(ctx, other, entity.UserFieldEmail)
+	assertErr(t, err, errors.ErrDuplicatedKey)
+}
+
+func testGetManyByEmail(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	first := createUser(t, dom, "first@example.com")
+	createUser(t, dom, "second@example.com")
+	deleted := createUser(t, dom, "deleted@example.com")
+
+	if err := dom.User.Delete(ctx, deleted); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	found, err := dom.User.GetManyByEmail(ctx, []string{"FIRST@example.com", "deleted@example.com", "unknown@example.com"})
+	if err != nil {
+		t.Fatalf("get many by email: %v", err)
+	}
+	if len(found) != 1 || found[0].Id != first.Id {
+		t.Fatalf("got %+v, want only %v", found, first.Id)
+	}
+}
+
+func testUpdate(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	user := createUser(t, dom, "update@example.com")
+
+	user.Name = "renamed"
+	user.Role = ""
+	updated, err := dom.User.Update(ctx, user, entity.UserFieldName, entity.UserFieldRole)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Name != "renamed" || updated.Role != "" || updated.Email != "update@example.com" || updated.Version != 2 {
+		t.Fatalf("got %+v after updating name and clearing role", updated)
+	}
+
+	_, err = dom.User.Update(ctx, entity.User{Id: primitive.NewObjectID(), Name: "missing"})
+	assertErr(t, err, errors.ErrNotFound)
+}
+
+func testVersionPrecondition(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	user := createUser(t, dom, "version@example.com")
+
+	first := user
+	first.Name = "first"
+	updated, err := dom.User.Update(ctx, first, entity.UserFieldName)
+	if err != nil {
+		t.Fatalf("update at version 1: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Fatalf("got version %v after update, want 2", updated.Version)
+	}
+
+	// a second writer that read version 1 loses
+	second := user
+	second.Name = "second"
+	_, err = dom.User.Update(ctx, second, entity.UserFieldName)
+	assertErr(t, err, errors.ErrPreconditionFailed)
+
+	assertErr(t, dom.User.Delete(ctx, user), errors.ErrPreconditionFailed)
+
+	// without a version the write applies to whatever is stored
+	second.Version = 0
+	updated, err = dom.User.Update(ctx, second, entity.UserFieldName)
+	if err != nil {
+		t.Fatalf("update without version: %v", err)
+	}
+	if updated.Name != "second" || updated.Version != 3 {
+		t.Fatalf("got name %v and version %v, want second and 3", updated.Name, updated.Version)
+	}
+}
+
+func testPasswordReset(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	created, errs := dom.User.CreateMany(ctx, []entity.User{{Name: "name", Email: "imported@example.com", PasswordResetRequired: true}})
+	if errs[0] != nil {
+		t.Fatalf("create: %v", errs[0])
+	}
+
+	user, err := dom.User.Get(ctx, entity.User{Id: created[0].Id})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !user.PasswordResetRequired {
+		t.Fatal("got no password reset required for imported user")
+	}
+
+	renamed, err := dom.User.Update(ctx, entity.User{Id: user.Id, Name: "renamed"}, entity.UserFieldName)
+	if err != nil {
+		t.Fatalf("update name: %v", err)
+	}
+	if !renamed.PasswordResetRequired {
+		t.Fatal("got password reset cleared by updating name")
+	}
+
+	reset, err := dom.User.Update(ctx, entity.User{Id: user.Id, Password: "hash"}, entity.UserFieldPassword)
+	if err != nil {
+		t.Fatalf("update password: %v", err)
+	}
+	if reset.PasswordResetRequired || reset.Password != "hash" {
+		t.Fatalf("got password reset required %v and password %q after setting password", reset.PasswordResetRequired, reset.Password)
+	}
+}
+
+func testSoftDelete(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	user := createUser(t, dom, "delete@example.com")
+
+	if err := dom.User.Delete(ctx, user); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err := dom.User.Get(ctx, entity.User{Id: user.Id})
+	assertErr(t, err, errors.ErrNotFound)
+
+	_, err = dom.User.Get(ctx, entity.User{Email: user.Email})
+	assertErr(t, err, errors.ErrNotFound)
+
+	assertErr(t, dom.User.Delete(ctx, entity.User{Id: user.Id}), errors.ErrNotFound)
+
+	user.Version = 0
+	_, err = dom.User.Update(ctx, user)
+	assertErr(t, err, errors.ErrNotFound)
+
+	page, err := dom.User.ListPage(ctx, entity.UserQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(page.Users) != 0 {
+		t.Fatalf("got %v users listed, want none", len(page.Users))
+	}
+
+	deleted, err := dom.User.ListPage(ctx, entity.UserQuery{Limit: 10, Deleted: true})
+	if err != nil {
+		t.Fatalf("list deleted: %v", err)
+	}
+	if len(deleted.Users) != 1 || deleted.Users[0].Id != user.Id || deleted.Users[0].DeletedAt.IsZero() {
+		t.Fatalf("got %+v listed as deleted, want only %v", deleted.Users, user.Id)
+	}
+
+	// the email is free again once its user is deleted
+	createUser(t, dom, "delete@example.com")
+}
+
+func testDeleteMany(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	first := createUser(t, dom, "first@example.com")
+	second := createUser(t, dom, "second@example.com")
+	kept := createUser(t, dom, "kept@example.com")
+
+	if err := dom.User.Delete(ctx, entity.User{Id: second.Id}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	deleted, err := dom.User.DeleteMany(ctx, []primitive.ObjectID{first.Id, second.Id, primitive.NewObjectID()})
+	if err != nil {
+		t.Fatalf("delete many: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != first.Id {
+		t.Fatalf("got %v deleted, want only %v", deleted, first.Id)
+	}
+
+	if _, err := dom.User.Get(ctx, entity.User{Id: kept.Id}); err != nil {
+		t.Fatalf("get user that was not deleted: %v", err)
+	}
+}
+
+func testRestore(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	user := createUser(t, dom, "restore@example.com")
+
+	_, err := dom.User.Restore(ctx, user)
+	assertErr(t, err, errors.ErrNotFound)
+
+	if err := dom.User.Delete(ctx, user); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	restored, err := dom.User.Restore(ctx, entity.User{Id: user.Id})
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if !restored.DeletedAt.IsZero() || restored.Version != 3 {
+		t.Fatalf("got deleted at %v and version %v after restore, want zero and 3", restored.DeletedAt, restored.Version)
+	}
+
+	// a user can not be restored once someone else took its email
+	if err := dom.User.Delete(ctx, restored); err != nil {
+		t.Fatalf("delete restored: %v", err)
+	}
+	createUser(t, dom, "restore@example.com")
+
+	_, err = dom.User.Restore(ctx, entity.User{Id: user.Id})
+	assertErr(t, err, errors.ErrDuplicatedKey)
+}
+
+func testPurge(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	deleted := createUser(t, dom, "purged@example.com")
+	kept := createUser(t, dom, "kept@example.com")
+
+	if err := dom.User.Delete(ctx, deleted); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	purged, err := dom.User.Purge(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("purge before deletion: %v", err)
+	}
+	if purged != 0 {
+		t.Fatalf("purged %v users deleted after the cut off, want 0", purged)
+	}
+
+	purged, err = dom.User.Purge(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged %v users, want 1", purged)
+	}
+
+	_, err = dom.User.Restore(ctx, entity.User{Id: deleted.Id})
+	assertErr(t, err, errors.ErrNotFound)
+
+	if _, err := dom.User.Get(ctx, entity.User{Id: kept.Id}); err != nil {
+		t.Fatalf("get user that was not deleted: %v", err)
+	}
+}
+
+func testHistory(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	userId, otherId := primitive.NewObjectID(), primitive.NewObjectID()
+
+	actions := []string{entity.UserActionCreate, entity.UserActionUpdate, entity.UserActionDelete}
+	for _, action := range actions {
+		change := entity.UserChange{
+			Id:        primitive.NewObjectID(),
+			UserId:    userId,
+			Action:    action,
+			Changes:   []entity.FieldChange{{Field: entity.UserFieldName, OldValue: "old", NewValue: "new"}},
+			CreatedAt: time.Now(),
+		}
+		if err := dom.UserHistory.Create(ctx, change); err != nil {
+			t.Fatalf("record %v: %v", action, err)
+		}
+	}
+
+	if err := dom.UserHistory.Create(ctx, entity.UserChange{Id: primitive.NewObjectID(), UserId: otherId, Action: entity.UserActionCreate, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("record change of other user: %v", err)
+	}
+
+	first, err := dom.UserHistory.List(ctx, entity.UserHistoryQuery{UserId: userId, Limit: 2})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(first.Changes) != 2 || first.Changes[0].Action != entity.UserActionDelete || first.Changes[1].Action != entity.UserActionUpdate {
+		t.Fatalf("got %+v on first page, want delete then update", first.Changes)
+	}
+	if len(first.Changes[0].Changes) != 1 || first.Changes[0].Changes[0].NewValue != "new" {
+		t.Fatalf("got field changes %+v, want name changed to new", first.Changes[0].Changes)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("got no cursor on first page")
+	}
+
+	second, err := dom.UserHistory.List(ctx, entity.UserHistoryQuery{UserId: userId, Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("list second page: %v", err)
+	}
+	if len(second.Changes) != 1 || second.Changes[0].Action != entity.UserActionCreate || second.NextCursor != "" {
+		t.Fatalf("got %+v and cursor %q on second page, want only create", second.Changes, second.NextCursor)
+	}
+}
+
+func testRevocation(t *testing.T, dom *Domains) {
+	ctx := context.Background()
+	userId, otherId := primitive.NewObjectID(), primitive.NewObjectID()
+	// iat has whole seconds, so a token of the second of the revocation can not be told apart from an older one
+	now := time.Unix(time.Now().Unix(), 0)
+
+	if err := dom.RevokedToken.Create(ctx, entity.RevokedToken{Jti: "revoked", UserId: otherId, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("revoke token: %v", err)
+	}
+	if err := dom.RevokedToken.RevokeUser(ctx, userId); err != nil {
+		t.Fatalf("revoke user: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token entity.RevokedToken
+		want  bool
+	}{
+		{"revoked jti", entity.RevokedToken{Jti: "revoked", UserId: otherId, IssuedAt: now}, true},
+		{"other jti", entity.RevokedToken{Jti: "other", UserId: otherId, IssuedAt: now.Add(-time.Minute)}, false},
+		{"issued before user revocation", entity.RevokedToken{Jti: "before", UserId: userId, IssuedAt: now.Add(-time.Minute)}, true},
+		{"issued in the second of user revocation", entity.RevokedToken{Jti: "same", UserId: userId, IssuedAt: now}, true},
+		{"issued after user revocation", entity.RevokedToken{Jti: "after", UserId: userId, IssuedAt: now.Add(2 * time.Second)}, false},
+	}
+
+	for _, tt := range tests {
+		revoked, err := dom.RevokedToken.IsRevoked(ctx, tt.token)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.name, err)
+		}
+		if revoked != tt.want {
+			t.Fatalf("%v: got revoked %v, want %v", tt.name, revoked, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type refreshToken struct {
+	logger     *logrus.Logger
+	collection *mongo.Collection
+}
+
+type RefreshTokenInterface interface {
+	Get(ctx context.Context, filter entity.RefreshToken) (entity.RefreshToken, error)
+	Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error)
+	MarkRotated(ctx context.Context, token entity.RefreshToken) error
+	UnmarkRotated(ctx context.Context, token entity.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyId primitive.ObjectID) error
+	RevokeUser(ctx context.Context, userId primitive.ObjectID) error
+}
+
+// initRefreshToken creates refresh token domain
+func initRefreshToken(logger *logrus.Logger, db *mongo.Collection) RefreshTokenInterface {
+	return &refreshToken{
+		logger:     logger,
+		collection: db,
+	}
+}
+
+// Get returns specific refresh token by hash or id
+func (r *refreshToken) Get(ctx context.Context, req entity.RefreshToken) (entity.RefreshToken, error) {
+	token := entity.RefreshToken{}
+	filter := bson.M{"_id": req.Id}
+	if req.Hash != "" {
+		filter = bson.M{"hash": req.Hash}
+	}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&token)
+	if err != nil {
+		return token, errorAlias(err)
+	}
+
+	return token, nil
+}
+
+// Create creates new refresh token
+func (r *refreshToken) Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	res, err := r.collection.InsertOne(ctx, token)
+	if err != nil {
+		return token, errorAlias(err)
+	}
+
+	newToken, err := r.Get(ctx, entity.RefreshToken{Id: res.InsertedID.(primitive.ObjectID)})
+	if err != nil {
+		return newToken, errorAlias(err)
+	}
+
+	return newToken, nil
+}
+
+// MarkRotated flags refresh token as used, failing when it was already used before
+func (r *refreshToken) MarkRotated(ctx context.Context, token entity.RefreshToken) error {
+	filter := bson.M{"_id": token.Id, "rotated_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"rotated_at": time.Now()}}
+
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errorAlias(err)
+	}
+
+	if res.ModifiedCount < 1 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// UnmarkRotated makes refresh token usable again when its successor could not be issued
+func (r *refreshToken) UnmarkRotated(ctx context.Context, token entity.RefreshToken) error {
+	filter := bson.M{"_id": token.Id}
+	update := bson.M{"$unset": bson.M{"rotated_at": ""}}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every refresh token descended from the same login
+func (r *refreshToken) RevokeFamily(ctx context.Context, familyId primitive.ObjectID) error {
+	filter := bson.M{"family_id": familyId}
+	update := bson.M{"$set": bson.M{"revoked": true}}
+
+	if _, err := r.collection.UpdateMany(ctx, filter, update); err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// RevokeUser revokes every refresh token owned by user
+func (r *refreshToken) RevokeUser(ctx context.Context, userId primitive.ObjectID) error {
+	filter := bson.M{"user_id": userId}
+	update := bson.M{"$set": bson.M{"revoked": true}}
+
+	if _, err := r.collection.UpdateMany(ctx, filter, update); err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryRefreshToken keeps refresh tokens in memory, for running without a database
+type memoryRefreshToken struct {
+	logger *logrus.Logger
+	mu     sync.Mutex
+	tokens map[primitive.ObjectID]entity.RefreshToken
+}
+
+// initMemoryRefreshToken creates in memory refresh token domain
+func initMemoryRefreshToken(logger *logrus.Logger) RefreshTokenInterface {
+	return &memoryRefreshToken{
+		logger: logger,
+		tokens: map[primitive.ObjectID]entity.RefreshToken{},
+	}
+}
+
+// Get returns specific refresh token by hash or id
+func (r *memoryRefreshToken) Get(ctx context.Context, req entity.RefreshToken) (entity.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.Hash == "" {
+		token, ok := r.tokens[req.Id]
+		if !ok {
+			return entity.RefreshToken{}, errors.ErrNotFound
+		}
+
+		return token, nil
+	}
+
+	for _, token := range r.tokens {
+		if token.Hash == req.Hash {
+			return token, nil
+		}
+	}
+
+	return entity.RefreshToken{}, errors.ErrNotFound
+}
+
+// Create creates new refresh token
+func (r *memoryRefreshToken) Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token.Id.IsZero() {
+		token.Id = primitive.NewObjectID()
+	}
+
+	if _, ok := r.tokens[token.Id]; ok {
+		return token, errors.ErrDuplicatedKey
+	}
+
+	// the plain token is never stored
+	token.Token = ""
+	r.tokens[token.Id] = token
+
+	return token, nil
+}
+
+// MarkRotated flags refresh token as used, failing when it was already used before
+func (r *memoryRefreshToken) MarkRotated(ctx context.Context, token entity.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.tokens[token.Id]
+	if !ok || !current.RotatedAt.IsZero() {
+		return errors.ErrNotFound
+	}
+
+	current.RotatedAt = time.Now()
+	r.tokens[token.Id] = current
+
+	return nil
+}
+
+// UnmarkRotated makes refresh token usable again when its successor could not be issued
+func (r *memoryRefreshToken) UnmarkRotated(ctx context.Context, token entity.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.tokens[token.Id]; ok {
+		current.RotatedAt = time.Time{}
+		r.tokens[token.Id] = current
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every refresh token descended from the same login
+func (r *memoryRefreshToken) RevokeFamily(ctx context.Context, familyId primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.FamilyId == familyId {
+			token.Revoked = true
+			r.tokens[id] = token
+		}
+	}
+
+	return nil
+}
+
+// RevokeUser revokes every refresh token owned by user
+func (r *memoryRefreshToken) RevokeUser(ctx context.Context, userId primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.UserId == userId {
+			token.Revoked = true
+			r.tokens[id] = token
+		}
+	}
+
+	return nil
+}
+
+// memoryRevokedToken keeps the revocation list in memory, for running without a database
+type memoryRevokedToken struct {
+	logger *logrus.Logger
+	mu     sync.RWMutex
+	tokens map[string]entity.RevokedToken
+	users  map[primitive.ObjectID]time.Time
+}
+
+// initMemoryRevokedToken creates in memory revoked token domain
+func initMemoryRevokedToken(logger *logrus.Logger) RevokedTokenInterface {
+	return &memoryRevokedToken{
+		logger: logger,
+		tokens: map[string]entity.RevokedToken{},
+		users:  map[primitive.ObjectID]time.Time{},
+	}
+}
+
+// IsRevoked reports whether the token itself or every token of its user issued until it was revoked
+func (r *memoryRevokedToken) IsRevoked(ctx context.Context, token entity.RevokedToken) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.tokens[token.Jti]; ok && token.Jti != "" {
+		return true, nil
+	}
+
+	revokedAt, ok := r.users[token.UserId]
+	return ok && !token.IssuedAt.After(revokedAt), nil
+}
+
+// Create adds a single token to revocation list
+func (r *memoryRevokedToken) Create(ctx context.Context, token entity.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.RevokedAt = time.Now()
+	r.tokens[token.Jti] = token
+
+	return nil
+}
+
+// RevokeUser revokes every token of user issued until now, iat has whole seconds
+// so a token issued within the same second can not be told apart and is revoked too
+func (r *memoryRevokedToken) RevokeUser(ctx context.Context, userId primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users[userId] = time.Now()
+
+	return nil
+}
+
+// PurgeExpired removes single tokens that have expired, user wide revocations are kept since there is only one per user
+func (r *memoryRevokedToken) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := int64(0)
+	for jti, token := range r.tokens {
+		if !token.ExpiresAt.IsZero() && token.ExpiresAt.Before(now) {
+			delete(r.tokens, jti)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sqlRefreshToken struct {
+	logger *logrus.Logger
+	db     *gorm.DB
+}
+
+// initSqlRefreshToken creates postgres refresh token domain
+func initSqlRefreshToken(logger *logrus.Logger, db *gorm.DB) RefreshTokenInterface {
+	return &sqlRefreshToken{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// Get returns specific refresh token by hash or id
+func (r *sqlRefreshToken) Get(ctx context.Context, req entity.RefreshToken) (entity.RefreshToken, error) {
+	tx := r.db.WithContext(ctx).Where("id = ?", req.Id.Hex())
+	if req.Hash != "" {
+		tx = r.db.WithContext(ctx).Where("hash = ?", req.Hash)
+	}
+
+	row := refreshTokenRow{}
+	if err := tx.First(&row).Error; err != nil {
+		return entity.RefreshToken{}, errorAlias(err)
+	}
+
+	return row.entity(), nil
+}
+
+// Create creates new refresh token
+func (r *sqlRefreshToken) Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	row := newRefreshTokenRow(token)
+	if err := r.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return token, errorAlias(err)
+	}
+
+	return row.entity(), nil
+}
+
+// MarkRotated flags refresh token as used, failing when it was already used before
+func (r *sqlRefreshToken) MarkRotated(ctx context.Context, token entity.RefreshToken) error {
+	res := r.db.WithContext(ctx).Model(&refreshTokenRow{}).
+		Where("id = ? AND rotated_at IS NULL", token.Id.Hex()).
+		Update("rotated_at", time.Now())
+	if res.Error != nil {
+		return errorAlias(res.Error)
+	}
+
+	if res.RowsAffected < 1 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// UnmarkRotated makes refresh token usable again when its successor could not be issued
+func (r *sqlRefreshToken) UnmarkRotated(ctx context.Context, token entity.RefreshToken) error {
+	err := r.db.WithContext(ctx).Model(&refreshTokenRow{}).Where("id = ?", token.Id.Hex()).Update("rotated_at", nil).Error
+	if err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every refresh token descended from the same login
+func (r *sqlRefreshToken) RevokeFamily(ctx context.Context, familyId primitive.ObjectID) error {
+	err := r.db.WithContext(ctx).Model(&refreshTokenRow{}).Where("family_id = ?", familyId.Hex()).Update("revoked", true).Error
+	if err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// RevokeUser revokes every refresh token owned by user
+func (r *sqlRefreshToken) RevokeUser(ctx context.Context, userId primitive.ObjectID) error {
+	err := r.db.WithContext(ctx).Model(&refreshTokenRow{}).Where("user_id = ?", userId.Hex()).Update("revoked", true).Error
+	if err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+type sqlRevokedToken struct {
+	logger *logrus.Logger
+	db     *gorm.DB
+}
+
+// initSqlRevokedToken creates postgres revoked token domain
+func initSqlRevokedToken(logger *logrus.Logger, db *gorm.DB) RevokedTokenInterface {
+	return &sqlRevokedToken{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// IsRevoked reports whether the token itself or every token of its user issued until it was revoked
+func (r *sqlRevokedToken) IsRevoked(ctx context.Context, token entity.RevokedToken) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&revokedTokenRow{}).
+		Where("jti = ? OR (user_id = ? AND jti IS NULL AND revoked_at >= ?)", token.Jti, token.UserId.Hex(), token.IssuedAt).
+		Count(&count).Error
+	if err != nil {
+		return false, errorAlias(err)
+	}
+
+	return count > 0, nil
+}
+
+// Create adds a single token to revocation list
+func (r *sqlRevokedToken) Create(ctx context.Context, token entity.RevokedToken) error {
+	row := revokedTokenRow{
+		Id:        hexId(token.Id),
+		Jti:       &token.Jti,
+		UserId:    token.UserId.Hex(),
+		ExpiresAt: nullTime(token.ExpiresAt),
+		RevokedAt: time.Now(),
+	}
+
+	if err := r.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// RevokeUser revokes every token of user issued until now, iat has whole seconds
+// so a token issued within the same second can not be told apart and is revoked too
+func (r *sqlRevokedToken) RevokeUser(ctx context.Context, userId primitive.ObjectID) error {
+	row := revokedTokenRow{
+		Id:        hexId(primitive.NilObjectID),
+		UserId:    userId.Hex(),
+		RevokedAt: time.Now(),
+	}
+
+	// there is a single user wide row per user, see revoked_tokens_user_unique
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "user_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "jti IS NULL"}}},
+		DoUpdates:   clause.AssignmentColumns([]string{"revoked_at"}),
+	}).Create(&row).Error
+	if err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// PurgeExpired removes single tokens that have expired, user wide rows are kept since there is only one per user
+func (r *sqlRevokedToken) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("jti IS NOT NULL AND expires_at < ?", now).Delete(&revokedTokenRow{})
+	if res.Error != nil {
+		return 0, errorAlias(res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
@@ -4,11 +4,15 @@ import (
 	"account-service/entity"
 	"account-service/errors"
 	"context"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type user struct {
@@ -18,10 +22,19 @@ type user struct {
 
 type UserInterface interface {
 	List(ctx context.Context) ([]entity.User, error)
+	ListPage(ctx context.Context, query entity.UserQuery) (entity.UserPage, error)
+	Stream(ctx context.Context, fn func(entity.User) error) error
 	Get(ctx context.Context, filter entity.User) (entity.User, error)
+	GetMany(ctx context.Context, ids []primitive.ObjectID) ([]entity.User, error)
+	GetManyByEmail(ctx context.Context, emails []string) ([]entity.User, error)
 	Create(ctx context.Context, user entity.User) (entity.User, error)
-	Update(ctx context.Context, user entity.User) (entity.User, error)
+	CreateMany(ctx context.Context, users []entity.User) ([]entity.User, []error)
+	Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error)
 	Delete(ctx context.Context, user entity.User) error
+	DeleteMany(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error)
+	Restore(ctx context.Context, user entity.User) (entity.User, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	TouchLogin(ctx context.Context, user entity.User) error
 }
 
 // initUser creates user domain
@@ -32,10 +45,14 @@ func initUser(logger *logrus.Logger, db *mongo.Collection) UserInterface {
 	}
 }
 
+// notDeleted matches users that are not soft deleted, null equality also matches a missing field and
+// unlike $exists false it is allowed in the partial filter of email_unique, so queries can use that index
+var notDeleted = bson.M{"deleted_at": nil}
+
 // List returns list of users
 func (s *user) List(ctx context.Context) ([]entity.User, error) {
 	users := []entity.User{}
-	cursor, err := s.collection.Find(ctx, bson.D{})
+	cursor, err := s.collection.Find(ctx, notDeleted)
 	if err != nil {
 		return users, errorAlias(err)
 	}
@@ -49,11 +66,146 @@ func (s *user) List(ctx context.Context) ([]entity.User, error) {
 	return users, nil
 }
 
-// Get returns specific user by email
+// ListPage returns a page of users matching query, ordered by query sort then id
+func (s *user) ListPage(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	page := entity.UserPage{Users: []entity.User{}}
+
+	field, direction := userSortField(query.Sort)
+	conditions := bson.A{notDeleted}
+	if query.Deleted {
+		conditions = bson.A{bson.M{"deleted_at": bson.M{"$exists": true}}}
+	}
+
+	if query.Email != "" {
+		conditions = append(conditions, bson.M{"email": query.Email})
+	}
+
+	if query.NamePrefix != "" {
+		conditions = append(conditions, bson.M{"name": bson.M{"$regex": "^" + regexp.QuoteMeta(query.NamePrefix)}})
+	}
+
+	// object id starts with its creation time, so created range is an id range
+	if !query.CreatedAfter.IsZero() {
+		conditions = append(conditions, bson.M{"_id": bson.M{"$gte": primitive.NewObjectIDFromTimestamp(query.CreatedAfter)}})
+	}
+
+	if !query.CreatedBefore.IsZero() {
+		conditions = append(conditions, bson.M{"_id": bson.M{"$lt": primitive.NewObjectIDFromTimestamp(query.CreatedBefore)}})
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return page, err
+		}
+
+		lastId, err := primitive.ObjectIDFromHex(cursor.Id)
+		if err != nil {
+			return page, errors.NewFieldError("Cursor", "malformed cursor")
+		}
+
+		operator := "$gt"
+		if direction < 0 {
+			operator = "$lt"
+		}
+
+		if field == "_id" {
+			conditions = append(conditions, bson.M{"_id": bson.M{operator: lastId}})
+		} else {
+			conditions = append(conditions, bson.M{"$or": bson.A{
+				bson.M{field: bson.M{operator: cursor.Value}},
+				bson.M{field: cursor.Value, "_id": bson.M{operator: lastId}},
+			}})
+		}
+	}
+
+	filter := bson.M{"$and": conditions}
+
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if field != "_id" {
+		sort = bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+	}
+
+	// one extra document tells whether there is a next page
+	opts := options.Find().SetSort(sort).SetLimit(int64(query.Limit) + 1)
+	if query.Email != "" {
+		opts.SetCollation(emailCollation)
+	}
+
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return page, errorAlias(err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &page.Users); err != nil {
+		return page, errorAlias(err)
+	}
+
+	if len(page.Users) > query.Limit {
+		page.Users = page.Users[:query.Limit]
+		last := page.Users[len(page.Users)-1]
+
+		next := pageCursor{Sort: query.Sort, Id: last.Id.Hex()}
+		switch field {
+		case "name":
+			next.Value = last.Name
+		case "email":
+			next.Value = last.Email
+		}
+		page.NextCursor = encodeCursor(next)
+	}
+
+	return page, nil
+}
+
+// Stream calls fn with every user that is not soft deleted in id order,
+// users are read through a cursor so they are never all held in memory
+func (s *user) Stream(ctx context.Context, fn func(entity.User) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := s.collection.Find(ctx, notDeleted, opts)
+	if err != nil {
+		return errorAlias(err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		user := entity.User{}
+		if err := cursor.Decode(&user); err != nil {
+			return errorAlias(err)
+		}
+
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+
+	return errorAlias(cursor.Err())
+}
+
+// userSortField returns document field and direction of user sort order
+func userSortField(sort string) (string, int) {
+	direction := 1
+	if strings.HasPrefix(sort, "-") {
+		direction = -1
+	}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case entity.UserSortName:
+		return "name", direction
+	case entity.UserSortEmail:
+		return "email", direction
+	default:
+		return "_id", direction
+	}
+}
+
+// Get returns specific user by email, soft deleted users are not found
 func (s *user) Get(ctx context.Context, req entity.User) (entity.User, error) {
-	s.logger.Debug(req)
+	s.logger.WithContext(ctx).WithField("filter", req).Debug("get user")
 	user := entity.User{}
-	var filter any
+	var filter bson.M
 
 	switch {
 	case req.Email != "":
@@ -63,8 +215,10 @@ func (s *user) Get(ctx context.Context, req entity.User) (entity.User, error) {
 	case req.Name != "":
 		filter = bson.M{"_id": req.Id}
 	}
+	filter["deleted_at"] = notDeleted["deleted_at"]
 
-	err := s.collection.FindOne(ctx, filter).Decode(&user)
+	// emails are unique regardless of case, see emailCollation
+	err := s.collection.FindOne(ctx, filter, options.FindOne().SetCollation(emailCollation)).Decode(&user)
 	if err != nil {
 		return user, errorAlias(err)
 	}
@@ -72,8 +226,49 @@ func (s *user) Get(ctx context.Context, req entity.User) (entity.User, error) {
 	return user, nil
 }
 
+// GetMany returns users with the given ids in no particular order, soft deleted users are not found
+func (s *user) GetMany(ctx context.Context, ids []primitive.ObjectID) ([]entity.User, error) {
+	users := []entity.User{}
+	cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "deleted_at": notDeleted["deleted_at"]})
+	if err != nil {
+		return users, errorAlias(err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &users); err != nil {
+		return users, errorAlias(err)
+	}
+
+	return users, nil
+}
+
+// GetManyByEmail returns users owning any of the emails regardless of case in no particular order,
+// soft deleted users are not found
+func (s *user) GetManyByEmail(ctx context.Context, emails []string) ([]entity.User, error) {
+	users := []entity.User{}
+	filter := bson.M{"email": bson.M{"$in": emails}, "deleted_at": notDeleted["deleted_at"]}
+
+	// emails are unique regardless of case, see emailCollation
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetCollation(emailCollation))
+	if err != nil {
+		return users, errorAlias(err)
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &users); err != nil {
+		return users, errorAlias(err)
+	}
+
+	return users, nil
+}
+
 // Create creates new data
 func (s *user) Create(ctx context.Context, user entity.User) (entity.User, error) {
+	now := time.Now()
+	user.Version = 1
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
 	res, err := s.collection.InsertOne(ctx, user)
 	if err != nil {
 		return user, errorAlias(err)
@@ -87,16 +282,88 @@ func (s *user) Create(ctx context.Context, user entity.User) (entity.User, error
 	return newUser, nil
 }
 
-// Update updates existing data
-func (s *user) Update(ctx context.Context, user entity.User) (entity.User, error) {
-	filter := bson.M{"_id": user.Id}
-	update := bson.M{"$set": user}
+// CreateMany creates users in a single unordered insert, so a failing user does not stop the others.
+// It returns users with their ids and the error of each user, nil for the created ones.
+func (s *user) CreateMany(ctx context.Context, users []entity.User) ([]entity.User, []error) {
+	errs := make([]error, len(users))
+	if len(users) == 0 {
+		return users, errs
+	}
 
-	_, err := s.collection.UpdateOne(ctx, filter, update)
+	now := time.Now()
+	docs := make([]any, len(users))
+	for i := range users {
+		users[i].Id = primitive.NewObjectID()
+		users[i].Version = 1
+		users[i].CreatedAt = now
+		users[i].UpdatedAt = now
+		docs[i] = users[i]
+	}
+
+	_, err := s.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+
+	var bulkErr mongo.BulkWriteException
+	switch {
+	case err == nil:
+	case errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil:
+		for _, writeErr := range bulkErr.WriteErrors {
+			errs[writeErr.Index] = errorAlias(mongo.WriteException{WriteErrors: mongo.WriteErrors{writeErr.WriteError}})
+		}
+	default:
+		for i := range errs {
+			errs[i] = errorAlias(err)
+		}
+	}
+
+	return users, errs
+}
+
+// Update updates existing data, only the given fields when any is given
+// so that those can be cleared, otherwise every non empty field.
+// When user has a version the update only applies to that version.
+func (s *user) Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error) {
+	filter := bson.M{"_id": user.Id, "deleted_at": notDeleted["deleted_at"]}
+	if user.Version != 0 {
+		filter["version"] = versionCondition(user.Version)
+	}
+
+	set := user
+	set.Id = primitive.NilObjectID
+	set.Version = 0
+	set.UpdatedAt = time.Now()
+	setFields, err := toDoc(set)
+	if err != nil {
+		return user, err
+	}
+	var unset []string
+
+	if len(fields) > 0 {
+		setFields = bson.M{"updated_at": set.UpdatedAt}
+		for _, field := range fields {
+			value := userFieldValue(user, field)
+			if value == "" {
+				unset = append(unset, field)
+			} else {
+				setFields[field] = value
+			}
+		}
+	}
+
+	// storing a password is the reset a user may be waiting for
+	if password, ok := setFields["password"]; ok && password != "" {
+		delete(setFields, "password_reset_required")
+		unset = append(unset, "password_reset_required")
+	}
+
+	res, err := s.collection.UpdateOne(ctx, filter, versionedUpdate(setFields, unset...))
 	if err != nil {
 		return user, errorAlias(err)
 	}
 
+	if res.MatchedCount < 1 {
+		return user, s.missingOrStale(ctx, user.Id)
+	}
+
 	newUser, err := s.Get(ctx, entity.User{Id: user.Id})
 	if err != nil {
 		return newUser, errorAlias(err)
@@ -105,18 +372,149 @@ func (s *user) Update(ctx context.Context, user entity.User) (entity.User, error
 	return newUser, nil
 }
 
-// Delete deletes existing data
+func userFieldValue(user entity.User, field string) string {
+	switch field {
+	case entity.UserFieldName:
+		return user.Name
+	case entity.UserFieldEmail:
+		return user.Email
+	case entity.UserFieldRole:
+		return user.Role
+	case entity.UserFieldPassword:
+		return user.Password
+	default:
+		return ""
+	}
+}
+
+// Delete soft deletes existing data, only at the given version when user has one
 func (s *user) Delete(ctx context.Context, user entity.User) error {
-	filter := bson.M{"_id": user.Id}
+	filter := bson.M{"_id": user.Id, "deleted_at": notDeleted["deleted_at"]}
+	if user.Version != 0 {
+		filter["version"] = versionCondition(user.Version)
+	}
+	update := versionedUpdate(bson.M{"deleted_at": time.Now(), "updated_at": time.Now()})
 
-	res, err := s.collection.DeleteOne(ctx, filter)
+	res, err := s.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return errorAlias(err)
 	}
 
-	if res.DeletedCount < 1 {
-		return errors.ErrNotFound
+	if res.MatchedCount < 1 {
+		return s.missingOrStale(ctx, user.Id)
+	}
+
+	return nil
+}
+
+// DeleteMany soft deletes users with the given ids regardless of version,
+// returning ids of the users it deleted, including those deleted before a failing update
+func (s *user) DeleteMany(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	deleted := []primitive.ObjectID{}
+
+	// one update per id tells which users this call deleted, a user deleted by someone else meanwhile is not matched
+	for _, id := range ids {
+		update := versionedUpdate(bson.M{"deleted_at": time.Now(), "updated_at": time.Now()})
+
+		res, err := s.collection.UpdateOne(ctx, bson.M{"_id": id, "deleted_at": notDeleted["deleted_at"]}, update)
+		if err != nil {
+			return deleted, errorAlias(err)
+		}
+
+		if res.MatchedCount > 0 {
+			deleted = append(deleted, id)
+		}
+	}
+
+	return deleted, nil
+}
+
+// Restore brings back soft deleted user
+func (s *user) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	filter := bson.M{"_id": user.Id, "deleted_at": bson.M{"$exists": true}}
+	update := versionedUpdate(bson.M{"updated_at": time.Now()}, "deleted_at")
+
+	res, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return user, errorAlias(err)
+	}
+
+	if res.MatchedCount < 1 {
+		return user, errors.ErrNotFound
+	}
+
+	return s.Get(ctx, entity.User{Id: user.Id})
+}
+
+// Purge hard deletes users soft deleted before the given time
+func (s *user) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	res, err := s.collection.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": deletedBefore}})
+	if err != nil {
+		return 0, errorAlias(err)
+	}
+
+	return res.DeletedCount, nil
+}
+
+// TouchLogin records last login time of user, it is not a change so version is kept
+func (s *user) TouchLogin(ctx context.Context, user entity.User) error {
+	filter := bson.M{"_id": user.Id}
+	update := bson.M{"$set": bson.M{"last_login_at": user.LastLoginAt}}
+
+	if _, err := s.collection.UpdateOne(ctx, filter, update); err != nil {
+		return errorAlias(err)
 	}
 
 	return nil
 }
+
+// missingOrStale tells why a versioned write matched nothing
+func (s *user) missingOrStale(ctx context.Context, id primitive.ObjectID) error {
+	err := s.collection.FindOne(ctx, bson.M{"_id": id, "deleted_at": notDeleted["deleted_at"]}).Err()
+	if err != nil {
+		return errorAlias(err)
+	}
+
+	return errors.ErrPreconditionFailed
+}
+
+// versionedUpdate sets and unsets fields while bumping version in one pipeline update. Users stored
+// before versioning have no version and count as 1, so their first write has to store 2, which $inc
+// on the missing field would not do. Values are literals so that strings starting with $ stay strings
+func versionedUpdate(set bson.M, unset ...string) mongo.Pipeline {
+	stage := bson.M{"version": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 1}}, 1}}}
+	for field, value := range set {
+		stage[field] = bson.M{"$literal": value}
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$set", Value: stage}}}
+	if len(unset) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$unset", Value: unset}})
+	}
+
+	return pipeline
+}
+
+// toDoc converts value to the document it is stored as, leaving out what its bson tags omit
+func toDoc(value any) (bson.M, error) {
+	raw, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := bson.M{}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// versionCondition matches version, users stored before versioning count as version 1
+func versionCondition(version int64) any {
+	if version == 1 {
+		return bson.M{"$in": bson.A{1, nil}}
+	}
+
+	return version
+}
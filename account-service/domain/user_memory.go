@@ -0,0 +1,444 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryUser keeps users in memory with the same semantics as user, for running without a database
+type memoryUser struct {
+	logger *logrus.Logger
+	mu     sync.RWMutex
+	users  map[primitive.ObjectID]entity.User
+}
+
+// initMemoryUser creates in memory user domain
+func initMemoryUser(logger *logrus.Logger) UserInterface {
+	return &memoryUser{
+		logger: logger,
+		users:  map[primitive.ObjectID]entity.User{},
+	}
+}
+
+// List returns list of users
+func (s *memoryUser) List(ctx context.Context) ([]entity.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []entity.User{}
+	for _, user := range s.users {
+		if user.DeletedAt.IsZero() {
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return compareIds(users[i].Id, users[j].Id) < 0
+	})
+
+	return users, nil
+}
+
+// Stream calls fn with every user that is not soft deleted in id order
+func (s *memoryUser) Stream(ctx context.Context, fn func(entity.User) error) error {
+	// a snapshot keeps fn free to call back into the domain
+	users, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListPage returns a page of users matching query, ordered by query sort then id
+func (s *memoryUser) ListPage(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	page := entity.UserPage{Users: []entity.User{}}
+
+	field, direction := userSortField(query.Sort)
+
+	var last *pageCursor
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return page, err
+		}
+
+		if _, err := primitive.ObjectIDFromHex(cursor.Id); err != nil {
+			return page, errors.NewFieldError("Cursor", "malformed cursor")
+		}
+		last = &cursor
+	}
+
+	s.mu.RLock()
+	users := []entity.User{}
+	for _, user := range s.users {
+		if query.Deleted == user.DeletedAt.IsZero() {
+			continue
+		}
+
+		if query.Email != "" && !strings.EqualFold(user.Email, query.Email) {
+			continue
+		}
+
+		if query.NamePrefix != "" && !strings.HasPrefix(user.Name, query.NamePrefix) {
+			continue
+		}
+
+		// object id starts with its creation time, so created range is an id range
+		if !query.CreatedAfter.IsZero() && compareIds(user.Id, primitive.NewObjectIDFromTimestamp(query.CreatedAfter)) < 0 {
+			continue
+		}
+
+		if !query.CreatedBefore.IsZero() && compareIds(user.Id, primitive.NewObjectIDFromTimestamp(query.CreatedBefore)) >= 0 {
+			continue
+		}
+
+		if last != nil && direction*compareUsers(user, *last, field) <= 0 {
+			continue
+		}
+
+		users = append(users, user)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		return direction*compareUsers(users[i], pageCursor{Value: sortValue(users[j], field), Id: users[j].Id.Hex()}, field) < 0
+	})
+
+	if len(users) > query.Limit {
+		users = users[:query.Limit]
+		last := users[len(users)-1]
+
+		next := pageCursor{Sort: query.Sort, Id: last.Id.Hex()}
+		if field != "_id" {
+			next.Value = sortValue(last, field)
+		}
+		page.NextCursor = encodeCursor(next)
+	}
+	page.Users = users
+
+	return page, nil
+}
+
+// compareUsers orders user against the position of cursor by sort field then id
+func compareUsers(user entity.User, cursor pageCursor, field string) int {
+	if field != "_id" {
+		if c := strings.Compare(sortValue(user, field), cursor.Value); c != 0 {
+			return c
+		}
+	}
+
+	id, _ := primitive.ObjectIDFromHex(cursor.Id)
+	return compareIds(user.Id, id)
+}
+
+func sortValue(user entity.User, field string) string {
+	switch field {
+	case "name":
+		return user.Name
+	case "email":
+		return user.Email
+	default:
+		return ""
+	}
+}
+
+func compareIds(a, b primitive.ObjectID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// Get returns specific user by email, soft deleted users are not found
+func (s *memoryUser) Get(ctx context.Context, req entity.User) (entity.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if req.Email == "" {
+		user, ok := s.users[req.Id]
+		if !ok || !user.DeletedAt.IsZero() {
+			return entity.User{}, errors.ErrNotFound
+		}
+
+		return user, nil
+	}
+
+	for _, user := range s.users {
+		if user.DeletedAt.IsZero() && strings.EqualFold(user.Email, req.Email) {
+			return user, nil
+		}
+	}
+
+	return entity.User{}, errors.ErrNotFound
+}
+
+// GetMany returns users with the given ids in no particular order, soft deleted users are not found
+func (s *memoryUser) GetMany(ctx context.Context, ids []primitive.ObjectID) ([]entity.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []entity.User{}
+	for _, id := range ids {
+		if user, ok := s.users[id]; ok && user.DeletedAt.IsZero() {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+// GetManyByEmail returns users owning any of the emails regardless of case in no particular order,
+// soft deleted users are not found
+func (s *memoryUser) GetManyByEmail(ctx context.Context, emails []string) ([]entity.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []entity.User{}
+	for _, user := range s.users {
+		if !user.DeletedAt.IsZero() {
+			continue
+		}
+
+		for _, email := range emails {
+			if strings.EqualFold(user.Email, email) {
+				users = append(users, user)
+				break
+			}
+		}
+	}
+
+	return users, nil
+}
+
+// Create creates new data
+func (s *memoryUser) Create(ctx context.Context, user entity.User) (entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user.Id.IsZero() {
+		user.Id = primitive.NewObjectID()
+	}
+
+	if _, ok := s.users[user.Id]; ok {
+		return user, errors.ErrDuplicatedKey
+	}
+
+	if s.emailTaken(user.Email, user.Id) {
+		return user, errors.ErrDuplicatedKey
+	}
+
+	now := time.Now()
+	user.Version = 1
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	s.users[user.Id] = user
+
+	return user, nil
+}
+
+// CreateMany creates users one by one, returning users with their ids and the error of each user
+func (s *memoryUser) CreateMany(ctx context.Context, users []entity.User) ([]entity.User, []error) {
+	errs := make([]error, len(users))
+	for i := range users {
+		users[i], errs[i] = s.Create(ctx, users[i])
+	}
+
+	return users, errs
+}
+
+// emailTaken tells whether another user that is not soft deleted has the email regardless of case
+func (s *memoryUser) emailTaken(email string, id primitive.ObjectID) bool {
+	if email == "" {
+		return false
+	}
+
+	for _, other := range s.users {
+		if other.Id != id && other.DeletedAt.IsZero() && strings.EqualFold(other.Email, email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Update updates existing data, only the given fields when any is given
+// so that those can be cleared, otherwise every non empty field.
+// When user has a version the update only applies to that version.
+func (s *memoryUser) Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.versioned(user)
+	if err != nil {
+		return user, err
+	}
+
+	updated := current
+	if len(fields) > 0 {
+		for _, field := range fields {
+			setUserField(&updated, field, userFieldValue(user, field))
+		}
+	} else {
+		for _, field := range []string{entity.UserFieldName, entity.UserFieldEmail, entity.UserFieldRole, entity.UserFieldPassword} {
+			if value := userFieldValue(user, field); value != "" {
+				setUserField(&updated, field, value)
+			}
+		}
+	}
+
+	if updated.Email != current.Email && s.emailTaken(updated.Email, updated.Id) {
+		return user, errors.ErrDuplicatedKey
+	}
+
+	updated.Version = storedVersion(current) + 1
+	updated.UpdatedAt = time.Now()
+	s.users[updated.Id] = updated
+
+	return updated, nil
+}
+
+func setUserField(user *entity.User, field, value string) {
+	switch field {
+	case entity.UserFieldName:
+		user.Name = value
+	case entity.UserFieldEmail:
+		user.Email = value
+	case entity.UserFieldRole:
+		user.Role = value
+	case entity.UserFieldPassword:
+		user.Password = value
+		if value != "" {
+			user.PasswordResetRequired = false
+		}
+	}
+}
+
+// Delete soft deletes existing data, only at the given version when user has one
+func (s *memoryUser) Delete(ctx context.Context, user entity.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.versioned(user)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	current.DeletedAt = now
+	current.UpdatedAt = now
+	current.Version = storedVersion(current) + 1
+	s.users[current.Id] = current
+
+	return nil
+}
+
+// DeleteMany soft deletes users with the given ids regardless of version,
+// returning ids of the users it deleted
+func (s *memoryUser) DeleteMany(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	deleted := []primitive.ObjectID{}
+	for _, id := range ids {
+		current, ok := s.users[id]
+		if !ok || !current.DeletedAt.IsZero() {
+			continue
+		}
+
+		current.DeletedAt = now
+		current.UpdatedAt = now
+		current.Version = storedVersion(current) + 1
+		s.users[id] = current
+		deleted = append(deleted, id)
+	}
+
+	return deleted, nil
+}
+
+// versioned returns stored user that is not soft deleted, matching version of user when it has one
+func (s *memoryUser) versioned(user entity.User) (entity.User, error) {
+	current, ok := s.users[user.Id]
+	if !ok || !current.DeletedAt.IsZero() {
+		return current, errors.ErrNotFound
+	}
+
+	if user.Version != 0 && storedVersion(current) != user.Version {
+		return current, errors.ErrPreconditionFailed
+	}
+
+	return current, nil
+}
+
+// storedVersion returns version of stored user, users stored before versioning count as version 1
+func storedVersion(user entity.User) int64 {
+	if user.Version == 0 {
+		return 1
+	}
+
+	return user.Version
+}
+
+// Restore brings back soft deleted user
+func (s *memoryUser) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.users[user.Id]
+	if !ok || current.DeletedAt.IsZero() {
+		return user, errors.ErrNotFound
+	}
+
+	if s.emailTaken(current.Email, current.Id) {
+		return user, errors.ErrDuplicatedKey
+	}
+
+	current.DeletedAt = time.Time{}
+	current.UpdatedAt = time.Now()
+	current.Version = storedVersion(current) + 1
+	s.users[current.Id] = current
+
+	return current, nil
+}
+
+// Purge hard deletes users soft deleted before the given time
+func (s *memoryUser) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, user := range s.users {
+		if !user.DeletedAt.IsZero() && user.DeletedAt.Before(deletedBefore) {
+			delete(s.users, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// TouchLogin records last login time of user, it is not a change so version is kept
+func (s *memoryUser) TouchLogin(ctx context.Context, user entity.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.users[user.Id]; ok {
+		current.LastLoginAt = user.LastLoginAt
+		s.users[user.Id] = current
+	}
+
+	return nil
+}
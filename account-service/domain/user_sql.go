@@ -0,0 +1,402 @@
+package domain
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sqlUser struct {
+	logger *logrus.Logger
+	db     *gorm.DB
+}
+
+// initSqlUser creates postgres user domain
+func initSqlUser(logger *logrus.Logger, db *gorm.DB) UserInterface {
+	return &sqlUser{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// likeEscaper escapes pattern characters of LIKE, backslash being the default escape of postgres
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// List returns list of users
+func (s *sqlUser) List(ctx context.Context) ([]entity.User, error) {
+	rows := []userRow{}
+	if err := s.db.WithContext(ctx).Where("deleted_at IS NULL").Order("id").Find(&rows).Error; err != nil {
+		return []entity.User{}, errorAlias(err)
+	}
+
+	users := []entity.User{}
+	for _, row := range rows {
+		users = append(users, row.entity())
+	}
+
+	return users, nil
+}
+
+// Stream calls fn with every user that is not soft deleted in id order,
+// rows are read one at a time so they are never all held in memory
+func (s *sqlUser) Stream(ctx context.Context, fn func(entity.User) error) error {
+	rows, err := s.db.WithContext(ctx).Model(&userRow{}).Where("deleted_at IS NULL").Order("id").Rows()
+	if err != nil {
+		return errorAlias(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		row := userRow{}
+		if err := s.db.ScanRows(rows, &row); err != nil {
+			return errorAlias(err)
+		}
+
+		if err := fn(row.entity()); err != nil {
+			return err
+		}
+	}
+
+	return errorAlias(rows.Err())
+}
+
+// ListPage returns a page of users matching query, ordered by query sort then id
+func (s *sqlUser) ListPage(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	page := entity.UserPage{Users: []entity.User{}}
+
+	field, direction := userSortField(query.Sort)
+	column := field
+	if field == "_id" {
+		column = "id"
+	}
+
+	tx := s.db.WithContext(ctx).Model(&userRow{}).Where("deleted_at IS NULL")
+	if query.Deleted {
+		tx = s.db.WithContext(ctx).Model(&userRow{}).Where("deleted_at IS NOT NULL")
+	}
+
+	if query.Email != "" {
+		tx = tx.Where("lower(email) = lower(?)", query.Email)
+	}
+
+	if query.NamePrefix != "" {
+		tx = tx.Where("name LIKE ?", likeEscaper.Replace(query.NamePrefix)+"%")
+	}
+
+	// ids are object ids, so created range is an id range
+	if !query.CreatedAfter.IsZero() {
+		tx = tx.Where("id >= ?", primitive.NewObjectIDFromTimestamp(query.CreatedAfter).Hex())
+	}
+
+	if !query.CreatedBefore.IsZero() {
+		tx = tx.Where("id < ?", primitive.NewObjectIDFromTimestamp(query.CreatedBefore).Hex())
+	}
+
+	operator, order := ">", "ASC"
+	if direction < 0 {
+		operator, order = "<", "DESC"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return page, err
+		}
+
+		if _, err := primitive.ObjectIDFromHex(cursor.Id); err != nil {
+			return page, errors.NewFieldError("Cursor", "malformed cursor")
+		}
+
+		if column == "id" {
+			tx = tx.Where("id "+operator+" ?", cursor.Id)
+		} else {
+			tx = tx.Where(fmt.Sprintf("(%v, id) %v (?, ?)", column, operator), cursor.Value, cursor.Id)
+		}
+	}
+
+	if column != "id" {
+		tx = tx.Order(column + " " + order)
+	}
+
+	// one extra row tells whether there is a next page
+	rows := []userRow{}
+	if err := tx.Order("id " + order).Limit(query.Limit + 1).Find(&rows).Error; err != nil {
+		return page, errorAlias(err)
+	}
+
+	for _, row := range rows {
+		page.Users = append(page.Users, row.entity())
+	}
+
+	if len(page.Users) > query.Limit {
+		page.Users = page.Users[:query.Limit]
+		last := page.Users[len(page.Users)-1]
+
+		next := pageCursor{Sort: query.Sort, Id: last.Id.Hex()}
+		if column != "id" {
+			next.Value = sortValue(last, field)
+		}
+		page.NextCursor = encodeCursor(next)
+	}
+
+	return page, nil
+}
+
+// Get returns specific user by email, soft deleted users are not found
+func (s *sqlUser) Get(ctx context.Context, req entity.User) (entity.User, error) {
+	tx := s.db.WithContext(ctx).Where("deleted_at IS NULL")
+	if req.Email != "" {
+		tx = tx.Where("lower(email) = lower(?)", req.Email)
+	} else {
+		tx = tx.Where("id = ?", req.Id.Hex())
+	}
+
+	row := userRow{}
+	if err := tx.First(&row).Error; err != nil {
+		return entity.User{}, errorAlias(err)
+	}
+
+	return row.entity(), nil
+}
+
+// GetMany returns users with the given ids in no particular order, soft deleted users are not found
+func (s *sqlUser) GetMany(ctx context.Context, ids []primitive.ObjectID) ([]entity.User, error) {
+	rows := []userRow{}
+	if err := s.db.WithContext(ctx).Where("id IN ? AND deleted_at IS NULL", hexIds(ids)).Find(&rows).Error; err != nil {
+		return []entity.User{}, errorAlias(err)
+	}
+
+	users := []entity.User{}
+	for _, row := range rows {
+		users = append(users, row.entity())
+	}
+
+	return users, nil
+}
+
+// GetManyByEmail returns users owning any of the emails regardless of case in no particular order,
+// soft deleted users are not found
+func (s *sqlUser) GetManyByEmail(ctx context.Context, emails []string) ([]entity.User, error) {
+	lowered := make([]string, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+
+	rows := []userRow{}
+	if err := s.db.WithContext(ctx).Where("lower(email) IN ? AND deleted_at IS NULL", lowered).Find(&rows).Error; err != nil {
+		return []entity.User{}, errorAlias(err)
+	}
+
+	users := []entity.User{}
+	for _, row := range rows {
+		users = append(users, row.entity())
+	}
+
+	return users, nil
+}
+
+// Create creates new data
+func (s *sqlUser) Create(ctx context.Context, user entity.User) (entity.User, error) {
+	now := time.Now()
+	user.Version = 1
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	row := newUserRow(user)
+	if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return user, errorAlias(err)
+	}
+
+	return s.Get(ctx, entity.User{Id: objectId(row.Id)})
+}
+
+// CreateMany creates users in a single transaction, a savepoint per user keeps a failing user
+// from aborting the others. It returns users with their ids and the error of each user.
+func (s *sqlUser) CreateMany(ctx context.Context, users []entity.User) ([]entity.User, []error) {
+	errs := make([]error, len(users))
+	if len(users) == 0 {
+		return users, errs
+	}
+
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range users {
+			users[i].Version = 1
+			users[i].CreatedAt = now
+			users[i].UpdatedAt = now
+
+			row := newUserRow(users[i])
+			users[i].Id = objectId(row.Id)
+
+			if err := tx.SavePoint("user").Error; err != nil {
+				return err
+			}
+
+			if err := tx.Create(&row).Error; err != nil {
+				errs[i] = errorAlias(err)
+				if err := tx.RollbackTo("user").Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		for i := range errs {
+			errs[i] = errorAlias(err)
+		}
+	}
+
+	return users, errs
+}
+
+// Update updates existing data, only the given fields when any is given
+// so that those can be cleared, otherwise every non empty field.
+// When user has a version the update only applies to that version.
+func (s *sqlUser) Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error) {
+	updates := map[string]any{}
+	if len(fields) > 0 {
+		for _, field := range fields {
+			updates[field] = userFieldValue(user, field)
+		}
+	} else {
+		for _, field := range []string{entity.UserFieldName, entity.UserFieldEmail, entity.UserFieldRole, entity.UserFieldPassword} {
+			if value := userFieldValue(user, field); value != "" {
+				updates[field] = value
+			}
+		}
+	}
+	if password, ok := updates[entity.UserFieldPassword]; ok && password != "" {
+		updates["password_reset_required"] = false
+	}
+	updates["updated_at"] = time.Now()
+	updates["version"] = gorm.Expr("version + 1")
+
+	if err := s.update(ctx, user, updates); err != nil {
+		return user, err
+	}
+
+	return s.Get(ctx, entity.User{Id: user.Id})
+}
+
+// Delete soft deletes existing data, only at the given version when user has one
+func (s *sqlUser) Delete(ctx context.Context, user entity.User) error {
+	now := time.Now()
+
+	return s.update(ctx, user, map[string]any{
+		"deleted_at": now,
+		"updated_at": now,
+		"version":    gorm.Expr("version + 1"),
+	})
+}
+
+// DeleteMany soft deletes users with the given ids regardless of version,
+// returning ids of the users it deleted
+func (s *sqlUser) DeleteMany(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	now := time.Now()
+
+	rows := []userRow{}
+	err := s.db.WithContext(ctx).Model(&rows).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("id IN ? AND deleted_at IS NULL", hexIds(ids)).
+		Updates(map[string]any{
+			"deleted_at": now,
+			"updated_at": now,
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+	if err != nil {
+		return nil, errorAlias(err)
+	}
+
+	deleted := []primitive.ObjectID{}
+	for _, row := range rows {
+		deleted = append(deleted, objectId(row.Id))
+	}
+
+	return deleted, nil
+}
+
+// update applies updates to user that is not soft deleted, at the given version when user has one
+func (s *sqlUser) update(ctx context.Context, user entity.User, updates map[string]any) error {
+	tx := s.db.WithContext(ctx).Model(&userRow{}).Where("id = ? AND deleted_at IS NULL", user.Id.Hex())
+	if user.Version != 0 {
+		tx = tx.Where("version = ?", user.Version)
+	}
+
+	res := tx.Updates(updates)
+	if res.Error != nil {
+		return errorAlias(res.Error)
+	}
+
+	if res.RowsAffected < 1 {
+		return s.missingOrStale(ctx, user.Id)
+	}
+
+	return nil
+}
+
+// Restore brings back soft deleted user
+func (s *sqlUser) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	res := s.db.WithContext(ctx).Model(&userRow{}).
+		Where("id = ? AND deleted_at IS NOT NULL", user.Id.Hex()).
+		Updates(map[string]any{
+			"deleted_at": nil,
+			"updated_at": time.Now(),
+			"version":    gorm.Expr("version + 1"),
+		})
+	if res.Error != nil {
+		return user, errorAlias(res.Error)
+	}
+
+	if res.RowsAffected < 1 {
+		return user, errors.ErrNotFound
+	}
+
+	return s.Get(ctx, entity.User{Id: user.Id})
+}
+
+// Purge hard deletes users soft deleted before the given time
+func (s *sqlUser) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("deleted_at < ?", deletedBefore).Delete(&userRow{})
+	if res.Error != nil {
+		return 0, errorAlias(res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
+
+// TouchLogin records last login time of user, it is not a change so version is kept
+func (s *sqlUser) TouchLogin(ctx context.Context, user entity.User) error {
+	err := s.db.WithContext(ctx).Model(&userRow{}).
+		Where("id = ?", user.Id.Hex()).
+		UpdateColumn("last_login_at", user.LastLoginAt).Error
+	if err != nil {
+		return errorAlias(err)
+	}
+
+	return nil
+}
+
+// missingOrStale tells why a versioned write matched nothing
+func (s *sqlUser) missingOrStale(ctx context.Context, id primitive.ObjectID) error {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&userRow{}).Where("id = ? AND deleted_at IS NULL", id.Hex()).Count(&count).Error
+	if err != nil {
+		return errorAlias(err)
+	}
+
+	if count == 0 {
+		return errors.ErrNotFound
+	}
+
+	return errors.ErrPreconditionFailed
+}
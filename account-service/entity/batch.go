@@ -0,0 +1,25 @@
+package entity
+
+const (
+	UserBatchNotFound  = "not_found"
+	UserBatchInvalidId = "invalid_id"
+)
+
+// UserBatchFailure tells why the user with Id was left out of a batch
+type UserBatchFailure struct {
+	Id     string
+	Code   string
+	Reason string
+}
+
+// UserBatchGet holds users found in the order of the requested ids
+type UserBatchGet struct {
+	Users    []User
+	Failures []UserBatchFailure
+}
+
+// UserBatchDelete holds ids of users deleted in the order of the requested ids
+type UserBatchDelete struct {
+	DeletedIds []string
+	Failures   []UserBatchFailure
+}
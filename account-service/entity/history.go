@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	UserActionCreate   = "create"
+	UserActionUpdate   = "update"
+	UserActionDelete   = "delete"
+	UserActionRestore  = "restore"
+	UserActionPassword = "password"
+)
+
+// UserChange records a single change made to user, ActorId is empty for changes made by the system
+type UserChange struct {
+	Id        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserId    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	ActorId   primitive.ObjectID `json:"actor_id" bson:"actor_id,omitempty"`
+	Action    string             `json:"action" bson:"action"`
+	Changes   []FieldChange      `json:"changes" bson:"changes,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type FieldChange struct {
+	Field    string `json:"field" bson:"field"`
+	OldValue string `json:"old_value" bson:"old_value,omitempty"`
+	NewValue string `json:"new_value" bson:"new_value,omitempty"`
+}
+
+type UserHistoryQuery struct {
+	UserId primitive.ObjectID
+	Limit  int
+	Cursor string
+}
+
+type UserHistory struct {
+	Changes    []UserChange
+	NextCursor string
+}
@@ -0,0 +1,46 @@
+package entity
+
+const (
+	UserImportCreated   = "created"
+	UserImportValid     = "valid"
+	UserImportDuplicate = "duplicate"
+	UserImportInvalid   = "invalid"
+)
+
+// UserImportRow is a user to import, Row is its position in the imported file
+type UserImportRow struct {
+	Row  int
+	User User
+}
+
+// UserImportResult tells what happened to a row, a valid row is one a dry run would have created
+type UserImportResult struct {
+	Row    int
+	Status string
+	Id     string
+	Email  string
+	Reason string
+}
+
+// UserImportReport counts results by status, Created counts valid rows on a dry run
+type UserImportReport struct {
+	DryRun     bool
+	Created    int
+	Duplicates int
+	Invalid    int
+	Results    []UserImportResult
+}
+
+// Add appends result to report, counting it by status
+func (r *UserImportReport) Add(result UserImportResult) {
+	switch result.Status {
+	case UserImportCreated, UserImportValid:
+		r.Created++
+	case UserImportDuplicate:
+		r.Duplicates++
+	case UserImportInvalid:
+		r.Invalid++
+	}
+
+	r.Results = append(r.Results, result)
+}
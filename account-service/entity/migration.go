@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// SchemaMigration records a migration applied to the database, AppliedAt is zero while pending
+type SchemaMigration struct {
+	Version   int       `json:"version" bson:"_id"`
+	Name      string    `json:"name" bson:"name"`
+	AppliedAt time.Time `json:"applied_at" bson:"applied_at,omitempty"`
+}
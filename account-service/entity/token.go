@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RefreshToken struct {
+	Id        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	UserId    primitive.ObjectID `json:"user_id" bson:"user_id,omitempty"`
+	FamilyId  primitive.ObjectID `json:"family_id" bson:"family_id,omitempty"`
+	Hash      string             `json:"-" bson:"hash,omitempty"`
+	Token     string             `json:"-" bson:"-"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at,omitempty"`
+	RotatedAt time.Time          `json:"rotated_at" bson:"rotated_at,omitempty"`
+	Revoked   bool               `json:"revoked" bson:"revoked"`
+}
+
+type RevokedToken struct {
+	Id        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Jti       string             `json:"jti,omitempty" bson:"jti,omitempty"`
+	UserId    primitive.ObjectID `json:"user_id" bson:"user_id,omitempty"`
+	IssuedAt  time.Time          `json:"issued_at" bson:"-"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at,omitempty"`
+	RevokedAt time.Time          `json:"revoked_at" bson:"revoked_at,omitempty"`
+}
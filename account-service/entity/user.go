@@ -1,12 +1,63 @@
 package entity
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
 
 type User struct {
-	Id       primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name     string             `json:"name" bson:"name,omitempty"`
-	Email    string             `json:"email" bson:"email,omitempty"`
-	Password string             `json:"password" bson:"password,omitempty"`
+	Id          primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name,omitempty"`
+	Email       string             `json:"email" bson:"email,omitempty"`
+	Password    string             `json:"password" bson:"password,omitempty"`
+	Role        string             `json:"role" bson:"role,omitempty"`
+	Version     int64              `json:"version" bson:"version,omitempty"`
+	DeletedAt   time.Time          `json:"deleted_at" bson:"deleted_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at,omitempty"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at,omitempty"`
+	LastLoginAt time.Time          `json:"last_login_at" bson:"last_login_at,omitempty"`
+	// PasswordResetRequired keeps user from logging in until a password is set, which clears it
+	PasswordResetRequired bool `json:"password_reset_required" bson:"password_reset_required,omitempty"`
+}
+
+// fields of user that can be named in an update mask
+const (
+	UserFieldName     = "name"
+	UserFieldEmail    = "email"
+	UserFieldRole     = "role"
+	UserFieldPassword = "password"
+)
+
+const (
+	UserSortCreated     = "created"
+	UserSortCreatedDesc = "-created"
+	UserSortName        = "name"
+	UserSortNameDesc    = "-name"
+	UserSortEmail       = "email"
+	UserSortEmailDesc   = "-email"
+)
+
+// UserQuery selects a page of users, Cursor is the NextCursor of the previous page
+type UserQuery struct {
+	Limit         int
+	Cursor        string
+	Sort          string
+	Email         string
+	NamePrefix    string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Deleted       bool
+}
+
+type UserPage struct {
+	Users      []User
+	NextCursor string
 }
 
 type UserCreateRequest struct {
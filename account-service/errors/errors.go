@@ -9,11 +9,38 @@ import (
 var (
 	ErrBadRequest          = fmt.Errorf("invalid request")
 	ErrUnauthorized        = fmt.Errorf("request unauthorized")
+	ErrForbidden           = fmt.Errorf("request forbidden")
 	ErrNotFound            = fmt.Errorf("resource not found")
 	ErrDuplicatedKey       = fmt.Errorf("request violate unique constraint")
+	ErrPreconditionFailed  = fmt.Errorf("resource version does not match")
 	ErrInternalServerError = fmt.Errorf("internal server error")
 )
 
+// FieldError describes a request field that failed validation, it is an ErrBadRequest
+type FieldError struct {
+	Field       string
+	Description string
+}
+
+func NewFieldError(field, description string) error {
+	return &FieldError{
+		Field:       field,
+		Description: description,
+	}
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Field, e.Description)
+}
+
+func (e *FieldError) Unwrap() error {
+	return ErrBadRequest
+}
+
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
+
 func Is(err error, target error) bool {
 	return errors.Is(err, target)
 }
@@ -24,10 +51,14 @@ func GetStatusCode(err error) (code int) {
 		code = http.StatusBadRequest
 	case errors.Is(err, ErrUnauthorized):
 		code = http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		code = http.StatusForbidden
 	case errors.Is(err, ErrNotFound):
 		code = http.StatusNotFound
 	case errors.Is(err, ErrDuplicatedKey):
 		code = http.StatusConflict
+	case errors.Is(err, ErrPreconditionFailed):
+		code = http.StatusPreconditionFailed
 	default:
 		code = http.StatusInternalServerError
 	}
@@ -0,0 +1,57 @@
+package errors
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ToStatus translates error into grpc status so clients can tell failures apart
+func ToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		st := status.New(codes.InvalidArgument, err.Error())
+
+		fieldErr := &FieldError{}
+		if errors.As(err, &fieldErr) {
+			withDetails, detailErr := st.WithDetails(&errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{
+					{Field: fieldErr.Field, Description: fieldErr.Description},
+				},
+			})
+			if detailErr == nil {
+				st = withDetails
+			}
+		}
+
+		return st.Err()
+	case errors.Is(err, ErrUnauthorized):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrDuplicatedKey):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrPreconditionFailed):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		// details of unexpected errors stay in our logs
+		return status.Error(codes.Internal, ErrInternalServerError.Error())
+	}
+}
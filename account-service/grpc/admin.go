@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// serveAdmin serves prometheus metrics on the admin port so that they are never exposed next to the grpc api
+func (g *grpcServer) serveAdmin() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	g.admin = &http.Server{
+		Addr:              fmt.Sprintf("%v:%v", g.cfg.Server.Base, g.cfg.Server.AdminPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := g.admin.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			g.log.Fatal(err)
+		}
+	}()
+	g.log.Infof("Listening and Serving admin HTTP on %v", g.admin.Addr)
+}
+
+// adminShutdownTimeout bounds closing the admin listener, a scrape in flight is not worth waiting longer for
+const adminShutdownTimeout = 5 * time.Second
+
+// shutdownAdmin stops the admin listener, it is a no-op when the admin port is not set.
+// It has a deadline of its own since the drain before it may have used up the shutdown timeout
+func (g *grpcServer) shutdownAdmin() {
+	if g.admin == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), adminShutdownTimeout)
+	defer cancel()
+
+	if err := g.admin.Shutdown(ctx); err != nil {
+		g.log.Errorf("failed to shut down admin listener. %v", err)
+	}
+}
@@ -2,13 +2,25 @@ package grpc
 
 import (
 	"account-service/config"
+	"account-service/domain"
+	accountv1 "account-service/grpc/account/v1"
 	"account-service/usecase"
+	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	grpc "google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/stats"
 )
 
 type GRPC interface {
@@ -16,33 +28,124 @@ type GRPC interface {
 }
 
 type grpcServer struct {
-	cfg    *config.Value
-	log    *logrus.Logger
-	server *grpc.Server
+	cfg     *config.Value
+	log     *logrus.Logger
+	server  *grpc.Server
+	health  *health.Server
+	storage domain.StorageInterface
+	admin   *http.Server
 }
 
-func Init(cfg *config.Value, log *logrus.Logger, uc *usecase.Usecases) GRPC {
-	s := grpc.NewServer()
+func Init(cfg *config.Value, log *logrus.Logger, uc *usecase.Usecases, storage domain.StorageInterface) GRPC {
+	// request id comes first so that every log of the rpc carries it,
+	// logging and metrics come next so that they see the status recovery and error translation end up with
+	unary := []grpc.UnaryServerInterceptor{requestIdUnaryInterceptor}
+	if cfg.Interceptor.Unary.Log {
+		unary = append(unary, logUnaryInterceptor(log))
+	}
+	if cfg.Interceptor.Unary.Metrics {
+		unary = append(unary, metricsUnaryInterceptor())
+	}
+	if cfg.Interceptor.Unary.Recovery {
+		unary = append(unary, recoveryUnaryInterceptor(log))
+	}
+	unary = append(unary, errorUnaryInterceptor(log), actorUnaryInterceptor)
 
+	stream := []grpc.StreamServerInterceptor{requestIdStreamInterceptor}
+	if cfg.Interceptor.Stream.Log {
+		stream = append(stream, logStreamInterceptor(log))
+	}
+	if cfg.Interceptor.Stream.Metrics {
+		stream = append(stream, metricsStreamInterceptor())
+	}
+	if cfg.Interceptor.Stream.Recovery {
+		stream = append(stream, recoveryStreamInterceptor(log))
+	}
+	stream = append(stream, errorStreamInterceptor(log), actorStreamInterceptor)
+
+	// the stats handler continues the trace of the caller, so spans of storage calls hang under it
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+
+	// legacy UserService is kept until every client calls account.v1
 	RegisterUserServiceServer(s, initUserGrpcServer(log, uc.User))
+	accountv1.RegisterUserServiceServer(s, initUserV1GrpcServer(log, uc.User))
+	RegisterTokenServiceServer(s, initTokenGrpcServer(log, uc.Token))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	if cfg.Server.Reflection {
+		reflection.Register(s)
+	}
 
 	return &grpcServer{
-		cfg:    cfg,
-		server: s,
-		log:    log,
+		cfg:     cfg,
+		server:  s,
+		log:     log,
+		health:  healthServer,
+		storage: storage,
 	}
 }
 
+// Run serves until SIGTERM or interrupt is received, then shuts the server down gracefully
 func (g *grpcServer) Run() {
 	listener, err := net.Listen("tcp", fmt.Sprintf("%v:%v", g.cfg.Server.Base, g.cfg.Server.Port))
 	if err != nil {
 		g.log.Fatal(err)
 	}
 
-	g.log.Info("Listening and Serving GRPC on :50051")
-	if err := g.server.Serve(listener); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	go g.watchHealth(ctx)
+
+	if g.cfg.Server.AdminPort != 0 {
+		g.serveAdmin()
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- g.server.Serve(listener)
+	}()
+	g.log.Infof("Listening and Serving GRPC on %v", listener.Addr())
+
+	select {
+	case err := <-served:
 		g.log.Fatal(err)
+	case <-ctx.Done():
 	}
+
+	g.shutdown()
+}
+
+// shutdown reports every service as not serving so that no new rpcs are routed here,
+// then waits for in flight rpcs until the drain deadline before cutting them off
+func (g *grpcServer) shutdown() {
+	g.log.Infof("shutting down, draining rpcs for up to %v", g.cfg.Server.ShutdownTimeout)
+	g.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		g.server.GracefulStop()
+		close(stopped)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		g.log.Warn("drain deadline passed, cancelling remaining rpcs")
+		g.server.Stop()
+	}
+
+	// metrics stay up while rpcs drain so that the drain itself is observable
+	g.shutdownAdmin()
 }
 
 // wrapper to connect to grpc package
@@ -54,3 +157,18 @@ func Dial(target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
 func WithInsecure() grpc.DialOption {
 	return grpc.WithTransportCredentials(insecure.NewCredentials())
 }
+
+// wrapper to connect to grpc package
+func WithStatsHandler(handler stats.Handler) grpc.DialOption {
+	return grpc.WithStatsHandler(handler)
+}
+
+// wrapper to connect to grpc package
+func WithUnaryInterceptor(interceptor grpc.UnaryClientInterceptor) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(interceptor)
+}
+
+// wrapper to connect to grpc package
+func WithStreamInterceptor(interceptor grpc.StreamClientInterceptor) grpc.DialOption {
+	return grpc.WithChainStreamInterceptor(interceptor)
+}
@@ -0,0 +1,52 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// watchHealth pings storage on every health check interval until ctx is done,
+// every service is serving only while storage is reachable
+func (g *grpcServer) watchHealth(ctx context.Context) {
+	ticker := time.NewTicker(g.cfg.Server.HealthCheckInterval)
+	defer ticker.Stop()
+
+	reachable := true
+	for {
+		pingCtx, cancel := context.WithTimeout(ctx, g.cfg.Server.HealthCheckInterval)
+		err := g.storage.Ping(pingCtx)
+		cancel()
+
+		// a ping cut short by shutdown says nothing about storage
+		if ctx.Err() != nil {
+			return
+		}
+
+		status := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		switch {
+		case err != nil && reachable:
+			g.log.Errorf("storage is unreachable, reporting not serving. %v", err)
+		case err == nil && !reachable:
+			g.log.Info("storage is reachable again, reporting serving")
+		}
+		reachable = err == nil
+
+		// empty name is the status of the server as a whole
+		g.health.SetServingStatus("", status)
+		for name := range g.server.GetServiceInfo() {
+			g.health.SetServingStatus(name, status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
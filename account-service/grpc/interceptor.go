@@ -0,0 +1,201 @@
+package grpc
+
+import (
+	"account-service/errors"
+	"account-service/logging"
+	"account-service/usecase"
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// actorMetadataKey carries id of the logged in user making the request
+const actorMetadataKey = "x-actor-id"
+
+// requestIdMetadataKey carries id of the gateway request the rpc is made for
+const requestIdMetadataKey = "x-request-id"
+
+// requestIdUnaryInterceptor puts the request id sent by the gateway into the context so that every log of the rpc carries it
+func requestIdUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withRequestId(ctx), req)
+}
+
+// requestIdStreamInterceptor puts the request id sent by the gateway into the stream context so that every log of the rpc carries it
+func requestIdStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: ss, ctx: withRequestId(ss.Context())})
+}
+
+// withRequestId falls back to a new id for clients calling without a valid one, logs of the rpc are still correlated
+func withRequestId(ctx context.Context) context.Context {
+	requestId := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(requestIdMetadataKey)) > 0 {
+		requestId = md.Get(requestIdMetadataKey)[0]
+	}
+
+	return logging.WithRequestId(ctx, logging.RequestId(requestId))
+}
+
+// logUnaryInterceptor writes an access log entry for every finished unary rpc
+func logUnaryInterceptor(log *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logRpc(ctx, log, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// logStreamInterceptor writes an access log entry for every finished stream rpc
+func logStreamInterceptor(log *logrus.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logRpc(ss.Context(), log, info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+// logRpc logs rpc at info level, at warn level when the client is at fault and at error level when the server is
+func logRpc(ctx context.Context, log *logrus.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	entry := log.WithContext(ctx).WithFields(logrus.Fields{
+		"method":      method,
+		"code":        code.String(),
+		"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+	})
+	if p, ok := peer.FromContext(ctx); ok {
+		entry = entry.WithField("peer", p.Addr.String())
+	}
+
+	switch code {
+	case codes.OK:
+		entry.Info("rpc finished")
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		entry.Error("rpc failed")
+	default:
+		entry.Warn("rpc rejected")
+	}
+}
+
+// recoveryUnaryInterceptor turns a panic of a unary handler into an internal error instead of a crash
+func recoveryUnaryInterceptor(log *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, log, info.FullMethod, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// recoveryStreamInterceptor turns a panic of a stream handler into an internal error instead of a crash
+func recoveryStreamInterceptor(log *logrus.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), log, info.FullMethod, r)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// recovered logs the panic with its stack, the client only learns that something went wrong
+func recovered(ctx context.Context, log *logrus.Logger, method string, r any) error {
+	log.WithContext(ctx).WithFields(logrus.Fields{"method": method, "stack": string(debug.Stack())}).Errorf("panic: %v", r)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// metricsUnaryInterceptor counts every finished unary rpc and its latency by method and status code
+func metricsUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeRpc(info.FullMethod, status.Code(err), time.Since(start))
+
+		return resp, err
+	}
+}
+
+// metricsStreamInterceptor counts every finished stream rpc and its latency by method and status code
+func metricsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeRpc(info.FullMethod, status.Code(err), time.Since(start))
+
+		return err
+	}
+}
+
+// errorUnaryInterceptor translates errors of unary handlers into grpc status
+func errorUnaryInterceptor(log *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, toStatus(ctx, log, info.FullMethod, err)
+	}
+}
+
+// errorStreamInterceptor translates errors of stream handlers into grpc status
+func errorStreamInterceptor(log *logrus.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatus(ss.Context(), log, info.FullMethod, handler(srv, ss))
+	}
+}
+
+// actorUnaryInterceptor puts the user acting on the request, sent by the gateway, into the context
+func actorUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withActor(ctx), req)
+}
+
+// actorStreamInterceptor puts the user acting on the request, sent by the gateway, into the stream context
+func actorStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: ss, ctx: withActor(ss.Context())})
+}
+
+func withActor(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(actorMetadataKey) {
+		if actorId, err := primitive.ObjectIDFromHex(value); err == nil {
+			return usecase.WithActor(ctx, actorId)
+		}
+	}
+
+	return ctx
+}
+
+// contextStream overrides context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func toStatus(ctx context.Context, log *logrus.Logger, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st := errors.ToStatus(err)
+	if status.Code(st) == codes.Internal {
+		log.WithContext(ctx).Errorf("%v: %v", method, err)
+	}
+
+	return st
+}
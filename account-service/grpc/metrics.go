@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	rpcsHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "account_grpc_server_handled_total",
+		Help: "Finished rpcs by method and status code.",
+	}, []string{"method", "code"})
+	rpcHandlingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "account_grpc_server_handling_seconds",
+		Help:    "Latency of finished rpcs by method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+// observeRpc counts a finished rpc and its latency
+func observeRpc(method string, code codes.Code, duration time.Duration) {
+	rpcsHandled.WithLabelValues(method, code.String()).Inc()
+	rpcHandlingDuration.WithLabelValues(method, code.String()).Observe(duration.Seconds())
+}
@@ -0,0 +1,140 @@
+package grpc
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"account-service/usecase"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+type tokenGrpcServer struct {
+	log   *logrus.Logger
+	token usecase.TokenInterface
+}
+
+func initTokenGrpcServer(log *logrus.Logger, token usecase.TokenInterface) *tokenGrpcServer {
+	return &tokenGrpcServer{
+		log:   log,
+		token: token,
+	}
+}
+
+func (t *tokenGrpcServer) mustEmbedUnimplementedTokenServiceServer() {}
+
+func (t *tokenGrpcServer) IssueRefreshToken(ctx context.Context, req *RefreshToken) (*RefreshToken, error) {
+	userId, err := primitive.ObjectIDFromHex(req.GetUserId())
+	if err != nil {
+		return nil, errors.NewFieldError("UserId", err.Error())
+	}
+
+	token, err := t.token.Issue(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &RefreshToken{
+		Token:     token.Token,
+		UserId:    token.UserId.Hex(),
+		ExpiresAt: token.ExpiresAt.Unix(),
+	}
+
+	return res, nil
+}
+
+func (t *tokenGrpcServer) CheckRefreshToken(ctx context.Context, req *RefreshToken) (*RefreshToken, error) {
+	token, err := t.token.Check(ctx, req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &RefreshToken{
+		UserId:    token.UserId.Hex(),
+		ExpiresAt: token.ExpiresAt.Unix(),
+	}
+
+	return res, nil
+}
+
+func (t *tokenGrpcServer) RotateRefreshToken(ctx context.Context, req *RefreshToken) (*RefreshToken, error) {
+	token, err := t.token.Rotate(ctx, req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &RefreshToken{
+		Token:     token.Token,
+		UserId:    token.UserId.Hex(),
+		ExpiresAt: token.ExpiresAt.Unix(),
+	}
+
+	return res, nil
+}
+
+func (t *tokenGrpcServer) RevokeRefreshToken(ctx context.Context, req *RefreshToken) (*emptypb.Empty, error) {
+	if err := t.token.RevokeRefreshToken(ctx, req.GetToken()); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (t *tokenGrpcServer) RevokeAccessToken(ctx context.Context, req *AccessToken) (*emptypb.Empty, error) {
+	userId, err := primitive.ObjectIDFromHex(req.GetUserId())
+	if err != nil {
+		return nil, errors.NewFieldError("UserId", err.Error())
+	}
+
+	if err := t.token.RevokeAccessToken(ctx, entity.RevokedToken{
+		Jti:       req.GetJti(),
+		UserId:    userId,
+		ExpiresAt: time.Unix(req.GetExpiresAt(), 0),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (t *tokenGrpcServer) RevokeUserTokens(ctx context.Context, req *AccessToken) (*emptypb.Empty, error) {
+	userId, err := primitive.ObjectIDFromHex(req.GetUserId())
+	if err != nil {
+		return nil, errors.NewFieldError("UserId", err.Error())
+	}
+
+	if err := t.token.RevokeUser(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (t *tokenGrpcServer) CheckAccessToken(ctx context.Context, req *AccessToken) (*AccessToken, error) {
+	userId, err := primitive.ObjectIDFromHex(req.GetUserId())
+	if err != nil {
+		return nil, errors.NewFieldError("UserId", err.Error())
+	}
+
+	revoked, err := t.token.IsRevoked(ctx, entity.RevokedToken{
+		Jti:      req.GetJti(),
+		UserId:   userId,
+		IssuedAt: time.Unix(req.GetIssuedAt(), 0),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AccessToken{
+		Jti:       req.GetJti(),
+		UserId:    req.GetUserId(),
+		IssuedAt:  req.GetIssuedAt(),
+		ExpiresAt: req.GetExpiresAt(),
+		Revoked:   revoked,
+	}
+
+	return res, nil
+}
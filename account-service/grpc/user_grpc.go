@@ -2,8 +2,10 @@ package grpc
 
 import (
 	"account-service/entity"
+	"account-service/errors"
 	"account-service/usecase"
 	"context"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -27,7 +29,7 @@ func (u *userGrpcServer) mustEmbedUnimplementedUserServiceServer() {}
 func (u *userGrpcServer) GetUser(ctx context.Context, req *User) (*User, error) {
 	id, err := primitive.ObjectIDFromHex(req.GetId())
 	if err != nil {
-		u.log.Error(err)
+		u.log.WithContext(ctx).Error(err)
 	}
 
 	user, err := u.user.Get(ctx, entity.User{
@@ -39,12 +41,7 @@ func (u *userGrpcServer) GetUser(ctx context.Context, req *User) (*User, error)
 		return nil, err
 	}
 
-	res := &User{
-		Id:       user.Id.Hex(),
-		Name:     user.Name,
-		Email:    user.Email,
-		Password: user.Password,
-	}
+	res := convertUser(user)
 
 	return res, nil
 }
@@ -54,41 +51,36 @@ func (u *userGrpcServer) AddUser(ctx context.Context, req *User) (*User, error)
 		Name:     req.GetName(),
 		Email:    req.GetEmail(),
 		Password: req.GetPassword(),
+		Role:     req.GetRole(),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	res := &User{
-		Id:    newUser.Id.Hex(),
-		Name:  newUser.Name,
-		Email: newUser.Email,
-	}
+	res := convertUser(newUser)
 
 	return res, nil
 }
 
 func (u *userGrpcServer) UpdateUser(ctx context.Context, req *User) (*User, error) {
-	id, err := primitive.ObjectIDFromHex(req.Id)
+	id, err := primitive.ObjectIDFromHex(req.GetId())
 	if err != nil {
-		return nil, err
+		return nil, errors.NewFieldError("Id", err.Error())
 	}
 
 	user, err := u.user.Update(ctx, entity.User{
-		Id:    id,
-		Name:  req.GetName(),
-		Email: req.GetEmail(),
+		Id:      id,
+		Name:    req.GetName(),
+		Email:   req.GetEmail(),
+		Role:    req.GetRole(),
+		Version: req.GetVersion(),
 	})
 	if err != nil {
 		return nil, err
 	}
-	u.log.Debug(user)
+	u.log.WithContext(ctx).WithField("user", user).Debug("user updated")
 
-	res := &User{
-		Id:    user.Id.Hex(),
-		Name:  user.Name,
-		Email: user.Email,
-	}
+	res := convertUser(user)
 
 	return res, nil
 }
@@ -96,11 +88,12 @@ func (u *userGrpcServer) UpdateUser(ctx context.Context, req *User) (*User, erro
 func (u *userGrpcServer) DeleteUser(ctx context.Context, req *User) (*emptypb.Empty, error) {
 	id, err := primitive.ObjectIDFromHex(req.Id)
 	if err != nil {
-		return nil, err
+		return nil, errors.NewFieldError("Id", err.Error())
 	}
 
 	if err := u.user.Delete(ctx, entity.User{
-		Id: id,
+		Id:      id,
+		Version: req.GetVersion(),
 	}); err != nil {
 		return nil, err
 	}
@@ -116,12 +109,32 @@ func (u *userGrpcServer) GetUsers(ctx context.Context, in *emptypb.Empty) (*User
 
 	res := &UserList{}
 	for i := range users {
-		res.Users = append(res.Users, &User{
-			Id:    users[i].Id.Hex(),
-			Name:  users[i].Name,
-			Email: users[i].Email,
-		})
+		res.Users = append(res.Users, convertUser(users[i]))
 	}
 
 	return res, nil
 }
+
+// convertUser converts user into its proto message, password hash is never included
+func convertUser(user entity.User) *User {
+	return &User{
+		Id:          user.Id.Hex(),
+		Name:        user.Name,
+		Email:       user.Email,
+		Role:        user.Role,
+		Version:     user.Version,
+		DeletedAt:   unixTime(user.DeletedAt),
+		CreatedAt:   unixTime(user.CreatedAt),
+		UpdatedAt:   unixTime(user.UpdatedAt),
+		LastLoginAt: unixTime(user.LastLoginAt),
+	}
+}
+
+// unixTime converts time to unix seconds, keeping zero time as zero
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
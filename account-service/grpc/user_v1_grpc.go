@@ -0,0 +1,367 @@
+package grpc
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	accountv1 "account-service/grpc/account/v1"
+	"account-service/usecase"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// userV1GrpcServer serves account.v1.UserService, the legacy UserService is served next to it until clients move over
+type userV1GrpcServer struct {
+	accountv1.UnimplementedUserServiceServer
+
+	log  *logrus.Logger
+	user usecase.UserInterface
+}
+
+func initUserV1GrpcServer(log *logrus.Logger, user usecase.UserInterface) *userV1GrpcServer {
+	return &userV1GrpcServer{
+		log:  log,
+		user: user,
+	}
+}
+
+func (u *userV1GrpcServer) GetUser(ctx context.Context, req *accountv1.GetUserRequest) (*accountv1.GetUserResponse, error) {
+	filter := entity.User{}
+	switch lookup := req.GetLookup().(type) {
+	case *accountv1.GetUserRequest_Id:
+		id, err := primitive.ObjectIDFromHex(lookup.Id)
+		if err != nil {
+			return nil, errors.NewFieldError("Id", err.Error())
+		}
+		filter.Id = id
+	case *accountv1.GetUserRequest_Email:
+		if lookup.Email == "" {
+			return nil, errors.NewFieldError("Email", "must not be empty")
+		}
+		filter.Email = lookup.Email
+	default:
+		return nil, errors.NewFieldError("Lookup", "id or email is required")
+	}
+
+	user, err := u.user.Get(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountv1.GetUserResponse{User: convertUserV1(user)}, nil
+}
+
+func (u *userV1GrpcServer) CreateUser(ctx context.Context, req *accountv1.CreateUserRequest) (*accountv1.CreateUserResponse, error) {
+	user, err := u.user.Create(ctx, entity.User{
+		Name:     req.GetName(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+		Role:     req.GetRole(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountv1.CreateUserResponse{User: convertUserV1(user)}, nil
+}
+
+func (u *userV1GrpcServer) UpdateUser(ctx context.Context, req *accountv1.UpdateUserRequest) (*accountv1.UpdateUserResponse, error) {
+	id, err := primitive.ObjectIDFromHex(req.GetUser().GetId())
+	if err != nil {
+		return nil, errors.NewFieldError("Id", err.Error())
+	}
+
+	fields := []string{}
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		fields = append(fields, strings.ToLower(path))
+	}
+
+	user, err := u.user.Update(ctx, entity.User{
+		Id:      id,
+		Name:    req.GetUser().GetName(),
+		Email:   req.GetUser().GetEmail(),
+		Role:    req.GetUser().GetRole(),
+		Version: req.GetUser().GetVersion(),
+	}, fields...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountv1.UpdateUserResponse{User: convertUserV1(user)}, nil
+}
+
+func (u *userV1GrpcServer) DeleteUser(ctx context.Context, req *accountv1.DeleteUserRequest) (*accountv1.DeleteUserResponse, error) {
+	id, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, errors.NewFieldError("Id", err.Error())
+	}
+
+	if err := u.user.Delete(ctx, entity.User{Id: id, Version: req.GetVersion()}); err != nil {
+		return nil, err
+	}
+
+	return &accountv1.DeleteUserResponse{}, nil
+}
+
+func (u *userV1GrpcServer) RestoreUser(ctx context.Context, req *accountv1.RestoreUserRequest) (*accountv1.RestoreUserResponse, error) {
+	id, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, errors.NewFieldError("Id", err.Error())
+	}
+
+	user, err := u.user.Restore(ctx, entity.User{Id: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountv1.RestoreUserResponse{User: convertUserV1(user)}, nil
+}
+
+func (u *userV1GrpcServer) ListUsers(ctx context.Context, req *accountv1.ListUsersRequest) (*accountv1.ListUsersResponse, error) {
+	page, err := u.user.ListPage(ctx, entity.UserQuery{
+		Limit:         int(req.GetPageSize()),
+		Cursor:        req.GetCursor(),
+		Sort:          req.GetSort(),
+		Email:         req.GetEmail(),
+		NamePrefix:    req.GetNamePrefix(),
+		CreatedAfter:  timeOfTimestamp(req.GetCreatedAfter()),
+		CreatedBefore: timeOfTimestamp(req.GetCreatedBefore()),
+		Deleted:       req.GetDeleted(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &accountv1.ListUsersResponse{
+		NextCursor: page.NextCursor,
+	}
+	for i := range page.Users {
+		res.Users = append(res.Users, convertUserV1(page.Users[i]))
+	}
+
+	return res, nil
+}
+
+func (u *userV1GrpcServer) StreamUsers(req *accountv1.StreamUsersRequest, stream accountv1.UserService_StreamUsersServer) error {
+	return u.user.Stream(stream.Context(), func(user entity.User) error {
+		return stream.Send(&accountv1.StreamUsersResponse{User: convertUserV1(user)})
+	})
+}
+
+func (u *userV1GrpcServer) ImportUsers(stream accountv1.UserService_ImportUsersServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return stream.SendAndClose(&accountv1.ImportUsersResponse{})
+	} else if err != nil {
+		return err
+	}
+
+	pending := first
+	next := func() (entity.UserImportRow, bool, error) {
+		req := pending
+		pending = nil
+
+		if req == nil {
+			var err error
+			req, err = stream.Recv()
+			if err == io.EOF {
+				return entity.UserImportRow{}, false, nil
+			} else if err != nil {
+				return entity.UserImportRow{}, false, err
+			}
+		}
+
+		return entity.UserImportRow{
+			Row: int(req.GetRow()),
+			User: entity.User{
+				Name:  req.GetName(),
+				Email: req.GetEmail(),
+				Role:  req.GetRole(),
+			},
+		}, true, nil
+	}
+
+	report, err := u.user.Import(stream.Context(), first.GetDryRun(), next)
+	if err != nil {
+		return err
+	}
+
+	res := &accountv1.ImportUsersResponse{
+		Created:    int32(report.Created),
+		Duplicates: int32(report.Duplicates),
+		Invalid:    int32(report.Invalid),
+		DryRun:     report.DryRun,
+	}
+	for _, result := range report.Results {
+		res.Results = append(res.Results, &accountv1.ImportUserResult{
+			Row:    int32(result.Row),
+			Status: result.Status,
+			Id:     result.Id,
+			Email:  result.Email,
+			Reason: result.Reason,
+		})
+	}
+
+	return stream.SendAndClose(res)
+}
+
+func (u *userV1GrpcServer) BatchGetUsers(ctx context.Context, req *accountv1.BatchGetUsersRequest) (*accountv1.BatchGetUsersResponse, error) {
+	batch, err := u.user.BatchGet(ctx, req.GetIds())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &accountv1.BatchGetUsersResponse{
+		Failures: convertBatchFailuresV1(batch.Failures),
+	}
+	for i := range batch.Users {
+		res.Users = append(res.Users, convertUserV1(batch.Users[i]))
+	}
+
+	return res, nil
+}
+
+func (u *userV1GrpcServer) BatchDeleteUsers(ctx context.Context, req *accountv1.BatchDeleteUsersRequest) (*accountv1.BatchDeleteUsersResponse, error) {
+	batch, err := u.user.BatchDelete(ctx, req.GetIds())
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountv1.BatchDeleteUsersResponse{
+		DeletedIds: batch.DeletedIds,
+		Failures:   convertBatchFailuresV1(batch.Failures),
+	}, nil
+}
+
+func (u *userV1GrpcServer) GetUserHistory(ctx context.Context, req *accountv1.GetUserHistoryRequest) (*accountv1.GetUserHistoryResponse, error) {
+	userId, err := primitive.ObjectIDFromHex(req.GetUserId())
+	if err != nil {
+		return nil, errors.NewFieldError("UserId", err.Error())
+	}
+
+	history, err := u.user.History(ctx, entity.UserHistoryQuery{
+		UserId: userId,
+		Limit:  int(req.GetPageSize()),
+		Cursor: req.GetCursor(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &accountv1.GetUserHistoryResponse{
+		NextCursor: history.NextCursor,
+	}
+	for _, change := range history.Changes {
+		res.Changes = append(res.Changes, convertUserChangeV1(change))
+	}
+
+	return res, nil
+}
+
+func (u *userV1GrpcServer) VerifyCredentials(ctx context.Context, req *accountv1.VerifyCredentialsRequest) (*accountv1.VerifyCredentialsResponse, error) {
+	user, err := u.user.VerifyCredentials(ctx, req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, err
+	}
+
+	return &accountv1.VerifyCredentialsResponse{User: convertUserV1(user)}, nil
+}
+
+func (u *userV1GrpcServer) SetPassword(ctx context.Context, req *accountv1.SetPasswordRequest) (*accountv1.SetPasswordResponse, error) {
+	id, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, errors.NewFieldError("Id", err.Error())
+	}
+
+	if err := u.user.SetPassword(ctx, id, req.GetPassword()); err != nil {
+		return nil, err
+	}
+
+	return &accountv1.SetPasswordResponse{}, nil
+}
+
+func (u *userV1GrpcServer) ChangePassword(ctx context.Context, req *accountv1.ChangePasswordRequest) (*accountv1.ChangePasswordResponse, error) {
+	id, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, errors.NewFieldError("Id", err.Error())
+	}
+
+	if err := u.user.ChangePassword(ctx, id, req.GetOldPassword(), req.GetNewPassword()); err != nil {
+		return nil, err
+	}
+
+	return &accountv1.ChangePasswordResponse{}, nil
+}
+
+// convertUserV1 converts user into its account.v1 message, password hash is never included
+func convertUserV1(user entity.User) *accountv1.User {
+	return &accountv1.User{
+		Id:          user.Id.Hex(),
+		Name:        user.Name,
+		Email:       user.Email,
+		Role:        user.Role,
+		Version:     user.Version,
+		CreatedAt:   timestamp(user.CreatedAt),
+		UpdatedAt:   timestamp(user.UpdatedAt),
+		LastLoginAt: timestamp(user.LastLoginAt),
+		DeletedAt:   timestamp(user.DeletedAt),
+
+		PasswordResetRequired: user.PasswordResetRequired,
+	}
+}
+
+// convertUserChangeV1 converts change into its account.v1 message, system changes have no actor
+func convertUserChangeV1(change entity.UserChange) *accountv1.UserChange {
+	res := &accountv1.UserChange{
+		Id:        change.Id.Hex(),
+		UserId:    change.UserId.Hex(),
+		Action:    change.Action,
+		CreatedAt: timestamp(change.CreatedAt),
+	}
+
+	if !change.ActorId.IsZero() {
+		res.ActorId = change.ActorId.Hex()
+	}
+
+	for _, field := range change.Changes {
+		res.Changes = append(res.Changes, &accountv1.FieldChange{Field: field.Field, OldValue: field.OldValue, NewValue: field.NewValue})
+	}
+
+	return res
+}
+
+func convertBatchFailuresV1(failures []entity.UserBatchFailure) []*accountv1.BatchFailure {
+	res := []*accountv1.BatchFailure{}
+	for _, failure := range failures {
+		res = append(res, &accountv1.BatchFailure{
+			Id:     failure.Id,
+			Code:   failure.Code,
+			Reason: failure.Reason,
+		})
+	}
+
+	return res
+}
+
+// timestamp converts time to its proto message, leaving zero time unset
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+// timeOfTimestamp converts proto timestamp to time, unset timestamp is zero time
+func timeOfTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// New creates the json logger every service writes with, entries carry the request id of their
+// context and have secrets masked, emails too when redactEmail is set
+func New(level string, redactEmail bool) (*logrus.Logger, error) {
+	logger := logrus.New()
+	logger.Out = os.Stdout
+	logger.ReportCaller = true
+	logger.Formatter = &logrus.JSONFormatter{
+		PrettyPrint:      true,
+		CallerPrettyfier: caller(),
+		TimestampFormat:  time.RFC3339,
+		FieldMap: logrus.FieldMap{
+			logrus.FieldKeyFile: "caller",
+		},
+	}
+
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	logger.Level = parsed
+	logger.AddHook(RequestIdHook{})
+	logger.AddHook(RedactHook{Email: redactEmail})
+
+	return logger, nil
+}
+
+func caller() func(*runtime.Frame) (function string, file string) {
+	return func(f *runtime.Frame) (function string, file string) {
+		p, _ := os.Getwd()
+
+		return "", fmt.Sprintf("%s:%d", strings.TrimPrefix(f.File, p), f.Line)
+	}
+}
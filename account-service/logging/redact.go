@@ -0,0 +1,223 @@
+package logging
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// redactedMask replaces every secret that would otherwise be logged
+const redactedMask = "[REDACTED]"
+
+// maxRedactDepth stops walking values that nest deeper, e.g. because they point to themselves
+const maxRedactDepth = 16
+
+// secretSuffixes is the registry of fields that are never logged, a field matches when its
+// lower case name without separators ends with one of them, e.g. refresh_token or SecretKey
+var secretSuffixes = []string{"password", "token", "secret", "secretkey", "authorization", "apikey", "dsn"}
+
+var (
+	// "name":"value" of json embedded in messages and fields, e.g. marshalled responses
+	jsonFieldPattern = regexp.MustCompile(`"([A-Za-z][A-Za-z0-9_-]*)"(\s*:\s*)"((?:[^"\\]|\\.)*)"`)
+	// Name:"value" of structs printed with %#v
+	goFieldPattern = regexp.MustCompile(`\b([A-Za-z][A-Za-z0-9_]*):"((?:[^"\\]|\\.)*)"`)
+	// name=value of query strings and key value dsn
+	keyValuePattern = regexp.MustCompile(`\b([A-Za-z][A-Za-z0-9_-]*)=([^\s&"]+)`)
+	// user:password@ of urls and url dsn, the password runs to the last @ before the path
+	// like url.Parse reads it, so a password with an unescaped @ is masked whole
+	credentialsPattern = regexp.MustCompile(`([A-Za-z][A-Za-z0-9+.-]*://[^:/@\s"]+):[^/\s"]*@`)
+	emailPattern       = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// RedactHook masks secrets, url credentials and, when Email is set, emails in the message and fields of every entry
+type RedactHook struct {
+	Email bool
+}
+
+func (RedactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h RedactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.text(entry.Message)
+	for key, value := range entry.Data {
+		entry.Data[key] = h.field(key, reflect.ValueOf(value), 0)
+	}
+
+	return nil
+}
+
+func (h RedactHook) secret(name string) bool {
+	name = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h RedactHook) emailField(name string) bool {
+	return h.Email && strings.HasSuffix(strings.ToLower(name), "email")
+}
+
+// field redacts value of the field called name, secrets are masked whatever their type
+func (h RedactHook) field(name string, v reflect.Value, depth int) any {
+	if h.secret(name) {
+		return redactedMask
+	}
+
+	if h.emailField(name) && v.Kind() == reflect.String {
+		return maskEmail(v.String())
+	}
+
+	return h.value(v, depth)
+}
+
+// value returns a copy of v with every secret masked, structs and maps become maps keyed like their json
+func (h RedactHook) value(v reflect.Value, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxRedactDepth {
+		return redactedMask
+	}
+	// nil pointers would panic in the methods of the types they point to
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil
+	}
+
+	if v.CanInterface() {
+		switch x := v.Interface().(type) {
+		case error:
+			return h.text(x.Error())
+		case time.Time, primitive.ObjectID:
+			// they hold nothing to mask and are logged the way they marshal
+			return x
+		case json.Marshaler:
+			// whatever else marshals itself may put secrets in its output, which is masked like a body
+			b, err := x.MarshalJSON()
+			if err != nil {
+				return redactedMask
+			}
+			if masked := h.text(string(b)); json.Valid([]byte(masked)) {
+				return json.RawMessage(masked)
+			}
+			return redactedMask
+		case encoding.TextMarshaler:
+			b, err := x.MarshalText()
+			if err != nil {
+				return redactedMask
+			}
+			return h.text(string(b))
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return h.value(v.Elem(), depth+1)
+	case reflect.String:
+		return h.text(v.String())
+	case reflect.Struct:
+		fields := map[string]any{}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			name := f.Name
+			if tag := f.Tag.Get("json"); tag != "" {
+				if tag == "-" {
+					continue
+				}
+				if jsonName, _, _ := strings.Cut(tag, ","); jsonName != "" {
+					name = jsonName
+				}
+			}
+			fields[name] = h.field(name, v.Field(i), depth+1)
+		}
+		return fields
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		fields := map[string]any{}
+		for iter := v.MapRange(); iter.Next(); {
+			fields[iter.Key().String()] = h.field(iter.Key().String(), iter.Value(), depth+1)
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = h.value(v.Index(i), depth+1)
+		}
+		return items
+	}
+
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return nil
+}
+
+// text masks secrets that are embedded in free text, e.g. messages and marshalled bodies
+func (h RedactHook) text(s string) string {
+	s = replaceSubmatches(jsonFieldPattern, s, func(m []string) string {
+		switch {
+		case h.secret(m[1]):
+			return `"` + m[1] + `"` + m[2] + `"` + redactedMask + `"`
+		case h.emailField(m[1]):
+			return `"` + m[1] + `"` + m[2] + `"` + maskEmail(m[3]) + `"`
+		}
+		return m[0]
+	})
+	s = replaceSubmatches(goFieldPattern, s, func(m []string) string {
+		switch {
+		case h.secret(m[1]):
+			return m[1] + `:"` + redactedMask + `"`
+		case h.emailField(m[1]):
+			return m[1] + `:"` + maskEmail(m[2]) + `"`
+		}
+		return m[0]
+	})
+	s = replaceSubmatches(keyValuePattern, s, func(m []string) string {
+		if h.secret(m[1]) {
+			return m[1] + "=" + redactedMask
+		}
+		return m[0]
+	})
+	s = credentialsPattern.ReplaceAllString(s, "${1}:"+redactedMask+"@")
+
+	if h.Email {
+		s = emailPattern.ReplaceAllStringFunc(s, maskEmail)
+	}
+
+	return s
+}
+
+// maskEmail keeps the first letter and the domain, which is usually enough to tell users apart while debugging
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 1 {
+		return redactedMask
+	}
+
+	return email[:1] + "***" + email[at:]
+}
+
+func replaceSubmatches(pattern *regexp.Regexp, s string, fn func(submatches []string) string) string {
+	return pattern.ReplaceAllStringFunc(s, func(match string) string {
+		return fn(pattern.FindStringSubmatch(match))
+	})
+}
@@ -0,0 +1,211 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRedactText(t *testing.T) {
+	type login struct {
+		Email    string
+		Password string
+	}
+
+	tests := []struct {
+		name  string
+		email bool
+		text  string
+		want  string
+	}{
+		{
+			name: "json token",
+			text: `{"token":"eyJhbGciOi","message":"ok"}`,
+			want: `{"token":"[REDACTED]","message":"ok"}`,
+		},
+		{
+			name: "json refresh token with spaces",
+			text: `{"refresh_token" : "abc\"def", "expires_in": "3600"}`,
+			want: `{"refresh_token" : "[REDACTED]", "expires_in": "3600"}`,
+		},
+		{
+			name: "go struct",
+			text: fmt.Sprintf("%#v", login{Email: "jane@example.com", Password: "hunter2"}),
+			want: `logging.login{Email:"jane@example.com", Password:"[REDACTED]"}`,
+		},
+		{
+			name: "query string",
+			text: "GET /api/callback?state=xyz&access_token=abc123&code=42",
+			want: "GET /api/callback?state=xyz&access_token=[REDACTED]&code=42",
+		},
+		{
+			name: "key value dsn",
+			text: "host=db user=app password=s3cret dbname=accounts sslmode=disable",
+			want: "host=db user=app password=[REDACTED] dbname=accounts sslmode=disable",
+		},
+		{
+			name: "url dsn",
+			text: "dial postgres://app:s3cret@db:5432/accounts failed",
+			want: "dial postgres://app:[REDACTED]@db:5432/accounts failed",
+		},
+		{
+			name: "url dsn with @ in password",
+			text: "dial mongodb://app:p@ss@word@db:27017/?authSource=admin failed",
+			want: "dial mongodb://app:[REDACTED]@db:27017/?authSource=admin failed",
+		},
+		{
+			name: "url without credentials",
+			text: "dial mongodb://db:27017/accounts",
+			want: "dial mongodb://db:27017/accounts",
+		},
+		{
+			name: "emails kept",
+			text: `user jane@example.com logged in, {"email":"jane@example.com"}`,
+			want: `user jane@example.com logged in, {"email":"jane@example.com"}`,
+		},
+		{
+			name:  "emails masked",
+			email: true,
+			text:  `user jane@example.com logged in, {"email":"jane@example.com"}`,
+			want:  `user j***@example.com logged in, {"email":"j***@example.com"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (RedactHook{Email: tt.email}).text(tt.text); got != tt.want {
+				t.Fatalf("got  %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// secretBody marshals itself, which must not let its token through
+type secretBody struct {
+	token string
+}
+
+func (b secretBody) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"access_token": b.token, "kind": "bearer"})
+}
+
+// secretText marshals itself to text, which must not let its password through
+type secretText struct{}
+
+func (secretText) MarshalText() ([]byte, error) {
+	return []byte("postgres://app:s3cret@db/accounts"), nil
+}
+
+func TestRedactFields(t *testing.T) {
+	type database struct {
+		DSN  string
+		Host string
+	}
+	type user struct {
+		Name         string `json:"name"`
+		Email        string `json:"email"`
+		Password     string `json:"-"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	id := primitive.NewObjectID()
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var missing *time.Time
+
+	tests := []struct {
+		name  string
+		email bool
+		key   string
+		value any
+		want  string
+	}{
+		{
+			name:  "secret field of any type",
+			key:   "api_key",
+			value: 42,
+			want:  `"[REDACTED]"`,
+		},
+		{
+			name:  "dsn",
+			key:   "config",
+			value: database{DSN: "host=db password=s3cret", Host: "db"},
+			want:  `{"DSN":"[REDACTED]","Host":"db"}`,
+		},
+		{
+			name:  "struct by json name",
+			key:   "user",
+			value: &user{Name: "jane", Email: "jane@example.com", Password: "hash", RefreshToken: "abc"},
+			want:  `{"email":"jane@example.com","name":"jane","refresh_token":"[REDACTED]"}`,
+		},
+		{
+			name:  "struct with emails masked",
+			email: true,
+			key:   "user",
+			value: user{Name: "jane", Email: "jane@example.com"},
+			want:  `{"email":"j***@example.com","name":"jane","refresh_token":"[REDACTED]"}`,
+		},
+		{
+			name:  "map",
+			key:   "headers",
+			value: map[string][]string{"Authorization": {"Bearer abc"}, "Accept": {"*/*"}},
+			want:  `{"Accept":["*/*"],"Authorization":"[REDACTED]"}`,
+		},
+		{
+			name:  "error",
+			key:   "error",
+			value: fmt.Errorf("dial postgres://app:s3cret@db/accounts"),
+			want:  `"dial postgres://app:[REDACTED]@db/accounts"`,
+		},
+		{
+			name:  "json marshaler",
+			key:   "body",
+			value: secretBody{token: "abc123"},
+			want:  `{"access_token":"[REDACTED]","kind":"bearer"}`,
+		},
+		{
+			name:  "text marshaler",
+			key:   "target",
+			value: secretText{},
+			want:  `"postgres://app:[REDACTED]@db/accounts"`,
+		},
+		{
+			name:  "object id",
+			key:   "id",
+			value: id,
+			want:  `"` + id.Hex() + `"`,
+		},
+		{
+			name:  "time",
+			key:   "at",
+			value: at,
+			want:  `"2024-01-02T03:04:05Z"`,
+		},
+		{
+			name:  "nil pointer",
+			key:   "last_login_at",
+			value: missing,
+			want:  `null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &logrus.Entry{Data: logrus.Fields{tt.key: tt.value}}
+			if err := (RedactHook{Email: tt.email}).Fire(entry); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.Marshal(entry.Data[tt.key])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// requestIdPattern is what a request id sent by a client must look like, anything else
+// could forge log lines or bloat every entry of the request so it is replaced
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestIdKey struct{}
+
+// WithRequestId returns ctx carrying the id that correlates logs of a request across services
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestIdFromContext returns the request id carried by ctx, empty when there is none
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// RequestId returns requestId when it is valid, otherwise a new one
+func RequestId(requestId string) string {
+	if requestIdPattern.MatchString(requestId) {
+		return requestId
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestIdHook adds the request id to every entry logged with a request context
+type RequestIdHook struct{}
+
+func (RequestIdHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (RequestIdHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	if requestId := RequestIdFromContext(entry.Context); requestId != "" {
+		entry.Data["request_id"] = requestId
+	}
+
+	return nil
+}
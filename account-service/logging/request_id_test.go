@@ -0,0 +1,35 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequestId(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestId string
+		kept      bool
+	}{
+		{"uuid", "0f8fad5b-d9cb-469f-a165-70867728950e", true},
+		{"dots and underscores", "edge_1.req-42", true},
+		{"longest", strings.Repeat("a", 64), true},
+		{"empty", "", false},
+		{"too long", strings.Repeat("a", 65), false},
+		{"newline", "abc\n{\"level\":\"info\"}", false},
+		{"space", "abc def", false},
+		{"non ascii", "ñandú", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RequestId(tt.requestId)
+			if tt.kept && got != tt.requestId {
+				t.Fatalf("got %q, want %q kept", got, tt.requestId)
+			}
+			if !tt.kept && (got == tt.requestId || !requestIdPattern.MatchString(got)) {
+				t.Fatalf("got %q, want a new valid id instead of %q", got, tt.requestId)
+			}
+		})
+	}
+}
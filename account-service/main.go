@@ -4,9 +4,17 @@ import (
 	"account-service/config"
 	"account-service/domain"
 	"account-service/grpc"
+	"account-service/migration"
+	"account-service/tracing"
 	"account-service/usecase"
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 // @contact.name Nafisa Alfiani
@@ -16,6 +24,12 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	// without a command the service is served, up applies pending migrations and status lists them
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
 	// init config
 	cfg, err := config.InitEnv()
 	if err != nil {
@@ -28,20 +42,168 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	logger.Info(fmt.Sprintf("%#v", cfg))
+	logger.WithField("config", cfg).Info("config loaded")
 
-	// init DB connection
-	db, err := config.InitNoSql(cfg)
+	// init tracer
+	tracer, err := tracing.Init(cfg.Trace, "account-service")
 	if err != nil {
-		logger.Fatalf("failed to connect to mongo. %v", err)
+		logger.Fatalf("failed to init tracer. %v", err)
+	}
+
+	// init domain, memory storage needs neither a database nor migrations
+	var dom *domain.Domains
+	switch cfg.Storage.Driver {
+	case config.StorageDriverMemory:
+		if command != "" {
+			logger.Fatalf("command %q needs a database, STORAGE_DRIVER is %v", command, cfg.Storage.Driver)
+		}
+
+		logger.Warn("storing data in memory, everything is lost on restart")
+		dom = domain.InitMemory(logger)
+	case config.StorageDriverPostgres:
+		dom = initPostgres(cfg, logger, command)
+	default:
+		dom = initMongo(cfg, logger, command)
+	}
+
+	// a command has already run, it only needs the storage closed
+	if command == "" {
+		serve(cfg, logger, dom)
 	}
 
-	// init domain
-	dom := domain.Init(db, logger)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := dom.Storage.Close(ctx); err != nil {
+		logger.Errorf("failed to close storage. %v", err)
+	}
+	if err := tracer.Shutdown(ctx); err != nil {
+		logger.Errorf("failed to flush spans. %v", err)
+	}
+	logger.Info("stopped")
+}
 
+// serve serves grpc until the process is signalled to stop
+func serve(cfg *config.Value, logger *logrus.Logger, dom *domain.Domains) {
 	// init handler
 	uc := usecase.Init(cfg, logger, dom)
 
-	g := grpc.Init(cfg, logger, uc)
+	if cfg.Auth.AdminEmail != "" {
+		if err := uc.User.EnsureAdmin(context.Background(), cfg.Auth.AdminEmail); err != nil {
+			logger.Fatalf("failed to grant admin role. %v", err)
+		}
+	}
+
+	// purging is stopped and waited for before the storage it runs against is closed
+	ctx, cancel := context.WithCancel(context.Background())
+	purged := make(chan struct{})
+	go func() {
+		defer close(purged)
+		uc.User.PurgeEvery(ctx, cfg.Purge.Interval)
+	}()
+
+	g := grpc.Init(cfg, logger, uc, dom.Storage)
 	g.Run()
+
+	cancel()
+	<-purged
+}
+
+// initMongo connects to mongo and prepares its schema, running command instead when one is given
+func initMongo(cfg *config.Value, logger *logrus.Logger, command string) *domain.Domains {
+	// init DB connection
+	db, err := config.InitNoSql(cfg)
+	if err != nil {
+		logger.Fatalf("failed to connect to mongo. %v", err)
+	}
+
+	if ran := runMigrations(cfg, logger, migration.Init(db.Database(domain.Database), logger), command); ran {
+		return domain.Init(db, logger)
+	}
+
+	if err := domain.EnsureIndexes(context.Background(), db, logger); err != nil {
+		logger.Fatalf("failed to ensure indexes. %v", err)
+	}
+
+	return domain.Init(db, logger)
+}
+
+// initPostgres connects to postgres and prepares its schema, running command instead when one is given
+func initPostgres(cfg *config.Value, logger *logrus.Logger, command string) *domain.Domains {
+	// init DB connection
+	db, err := config.InitSql(cfg)
+	if err != nil {
+		logger.Fatalf("failed to connect to postgres. %v", err)
+	}
+
+	runMigrations(cfg, logger, migration.InitSql(db, logger), command)
+
+	return domain.InitSql(db, logger)
+}
+
+// runMigrations runs command and reports it ran when one is given, otherwise it migrates
+// when MIGRATE_ON_BOOT is set or warns about pending migrations
+func runMigrations(cfg *config.Value, logger *logrus.Logger, migrator migration.MigratorInterface, command string) bool {
+	switch command {
+	case "up":
+		migrate(migrator, logger)
+		return true
+	case "status":
+		printMigrationStatus(migrator, logger)
+		return true
+	case "":
+	default:
+		logger.Fatalf("unknown command %q, expected up or status", command)
+	}
+
+	if cfg.Migration.OnBoot {
+		migrate(migrator, logger)
+	} else if pending := pendingMigrations(migrator, logger); pending > 0 {
+		logger.Warnf("%v migrations are pending, run with up or set MIGRATE_ON_BOOT", pending)
+	}
+
+	return false
+}
+
+func migrate(migrator migration.MigratorInterface, logger *logrus.Logger) {
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		logger.Fatalf("failed to migrate. %v", err)
+	}
+
+	logger.Infof("applied %v migrations", len(applied))
+}
+
+func pendingMigrations(migrator migration.MigratorInterface, logger *logrus.Logger) int {
+	status, err := migrator.Status(context.Background())
+	if err != nil {
+		logger.Fatalf("failed to read migration status. %v", err)
+	}
+
+	pending := 0
+	for _, migration := range status {
+		if migration.AppliedAt.IsZero() {
+			pending++
+		}
+	}
+
+	return pending
+}
+
+func printMigrationStatus(migrator migration.MigratorInterface, logger *logrus.Logger) {
+	status, err := migrator.Status(context.Background())
+	if err != nil {
+		logger.Fatalf("failed to read migration status. %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, migration := range status {
+		appliedAt := "pending"
+		if !migration.AppliedAt.IsZero() {
+			appliedAt = migration.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\n", migration.Version, migration.Name, appliedAt)
+	}
+	w.Flush()
 }
@@ -0,0 +1,116 @@
+package migration
+
+import (
+	"account-service/entity"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migration evolves stored documents or indexes, Up must be idempotent
+// since a crash between running it and recording it runs it again
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+}
+
+type migrator struct {
+	logger     *logrus.Logger
+	db         *mongo.Database
+	collection *mongo.Collection
+	migrations []Migration
+}
+
+type MigratorInterface interface {
+	Up(ctx context.Context) ([]entity.SchemaMigration, error)
+	Status(ctx context.Context) ([]entity.SchemaMigration, error)
+}
+
+// Init creates migrator running the registered migrations against db,
+// applied migrations are recorded in schema_migrations
+func Init(db *mongo.Database, logger *logrus.Logger) MigratorInterface {
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &migrator{
+		logger:     logger,
+		db:         db,
+		collection: db.Collection("schema_migrations"),
+		migrations: sorted,
+	}
+}
+
+// Up applies pending migrations in version order, stopping at the first failure
+func (m *migrator) Up(ctx context.Context) ([]entity.SchemaMigration, error) {
+	applied := []entity.SchemaMigration{}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		return applied, err
+	}
+
+	for i, migration := range m.migrations {
+		if !status[i].AppliedAt.IsZero() {
+			continue
+		}
+
+		m.logger.Infof("applying migration %v %v", migration.Version, migration.Name)
+		if err := migration.Up(ctx, m.db); err != nil {
+			return applied, fmt.Errorf("migration %v %v failed: %w", migration.Version, migration.Name, err)
+		}
+
+		record := entity.SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+		// another instance applying the same migration at once is fine since migrations are idempotent
+		if _, err := m.collection.InsertOne(ctx, record); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return applied, fmt.Errorf("failed to record migration %v %v: %w", migration.Version, migration.Name, err)
+		}
+
+		applied = append(applied, record)
+	}
+
+	return applied, nil
+}
+
+// Status lists every registered migration in version order with the time it was applied
+func (m *migrator) Status(ctx context.Context) ([]entity.SchemaMigration, error) {
+	cursor, err := m.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := []entity.SchemaMigration{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	appliedAt := map[int]time.Time{}
+	for _, record := range records {
+		appliedAt[record.Version] = record.AppliedAt
+	}
+
+	status := []entity.SchemaMigration{}
+	for _, migration := range m.migrations {
+		status = append(status, entity.SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: appliedAt[migration.Version],
+		})
+		delete(appliedAt, migration.Version)
+	}
+
+	// database was migrated by a newer binary, running this one against it may not be safe
+	for version := range appliedAt {
+		m.logger.Warnf("migration %v is applied but unknown to this build", version)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,93 @@
+package migration
+
+import (
+	"account-service/entity"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrations lists every migration, versions must be unique and never reused
+var migrations = []Migration{
+	{Version: 1, Name: "backfill_user_defaults", Up: backfillUserDefaults},
+	{Version: 2, Name: "normalize_user_emails", Up: normalizeUserEmails},
+	{Version: 3, Name: "partial_email_index", Up: partialEmailIndex},
+}
+
+// backfillUserDefaults stores the defaults that were only filled on read for users created
+// before role, version and timestamps existed, creation time comes from the object id
+func backfillUserDefaults(ctx context.Context, db *mongo.Database) error {
+	users := db.Collection("user")
+
+	if _, err := users.UpdateMany(ctx, bson.M{"role": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"role": entity.RoleUser}}); err != nil {
+		return err
+	}
+
+	if _, err := users.UpdateMany(ctx, bson.M{"version": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"version": 1}}); err != nil {
+		return err
+	}
+
+	_, err := users.UpdateMany(ctx, bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{"$exists": false}},
+		bson.M{"updated_at": bson.M{"$exists": false}},
+	}}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"created_at": bson.M{"$ifNull": bson.A{"$created_at", bson.M{"$toDate": "$_id"}}}}}},
+		{{Key: "$set", Value: bson.M{"updated_at": bson.M{"$ifNull": bson.A{"$updated_at", "$created_at"}}}}},
+	})
+
+	return err
+}
+
+// normalizeUserEmails lowercases and trims stored emails, matching what the user usecase stores since
+func normalizeUserEmails(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("user").UpdateMany(ctx, bson.M{"email": bson.M{"$type": "string"}}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"email": bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}}}}},
+	})
+
+	return err
+}
+
+// partialEmailIndex replaces email_unique, which covered soft deleted users, with one limited to users that
+// are not deleted. It is created here rather than left to domain.EnsureIndexes, which does not run along
+// with migration commands, and must match the spec there so that it is not reported as drifted
+func partialEmailIndex(ctx context.Context, db *mongo.Database) error {
+	indexes := db.Collection("user").Indexes()
+
+	cursor, err := indexes.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing := []struct {
+		Name    string `bson:"name"`
+		Partial bson.M `bson:"partialFilterExpression"`
+	}{}
+	if err := cursor.All(ctx, &existing); err != nil {
+		return err
+	}
+
+	for _, index := range existing {
+		if index.Name != "email_unique" {
+			continue
+		}
+		if index.Partial != nil {
+			return nil
+		}
+		if _, err := indexes.DropOne(ctx, index.Name); err != nil {
+			return err
+		}
+	}
+
+	_, err = indexes.CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().
+			SetName("email_unique").
+			SetUnique(true).
+			SetCollation(&options.Collation{Locale: "en", Strength: 2}).
+			SetPartialFilterExpression(bson.M{"deleted_at": nil}),
+	})
+
+	return err
+}
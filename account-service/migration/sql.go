@@ -0,0 +1,186 @@
+package migration
+
+import (
+	"account-service/entity"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SqlMigration evolves postgres tables, it runs in a transaction together with its record
+type SqlMigration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+type sqlMigrator struct {
+	logger     *logrus.Logger
+	db         *gorm.DB
+	migrations []SqlMigration
+}
+
+// InitSql creates migrator running the registered postgres migrations against db,
+// applied migrations are recorded in schema_migrations
+func InitSql(db *gorm.DB, logger *logrus.Logger) MigratorInterface {
+	sorted := append([]SqlMigration{}, sqlMigrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &sqlMigrator{
+		logger:     logger,
+		db:         db,
+		migrations: sorted,
+	}
+}
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version integer PRIMARY KEY,
+	name text NOT NULL,
+	applied_at timestamptz NOT NULL
+)`
+
+// Up applies pending migrations in version order, stopping at the first failure
+func (m *sqlMigrator) Up(ctx context.Context) ([]entity.SchemaMigration, error) {
+	applied := []entity.SchemaMigration{}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		return applied, err
+	}
+
+	for i, migration := range m.migrations {
+		if !status[i].AppliedAt.IsZero() {
+			continue
+		}
+
+		m.logger.Infof("applying migration %v %v", migration.Version, migration.Name)
+		record := entity.SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, statement := range migration.Statements {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				record.Version, record.Name, record.AppliedAt).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %v %v failed: %w", migration.Version, migration.Name, err)
+		}
+
+		applied = append(applied, record)
+	}
+
+	return applied, nil
+}
+
+// Status lists every registered migration in version order with the time it was applied
+func (m *sqlMigrator) Status(ctx context.Context) ([]entity.SchemaMigration, error) {
+	if err := m.db.WithContext(ctx).Exec(createSchemaMigrations).Error; err != nil {
+		return nil, err
+	}
+
+	records := []entity.SchemaMigration{}
+	if err := m.db.WithContext(ctx).Raw("SELECT version, name, applied_at FROM schema_migrations").Scan(&records).Error; err != nil {
+		return nil, err
+	}
+
+	appliedAt := map[int]time.Time{}
+	for _, record := range records {
+		appliedAt[record.Version] = record.AppliedAt
+	}
+
+	status := []entity.SchemaMigration{}
+	for _, migration := range m.migrations {
+		status = append(status, entity.SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: appliedAt[migration.Version],
+		})
+		delete(appliedAt, migration.Version)
+	}
+
+	// database was migrated by a newer binary, running this one against it may not be safe
+	for version := range appliedAt {
+		m.logger.Warnf("migration %v is applied but unknown to this build", version)
+	}
+
+	return status, nil
+}
+
+// sqlMigrations lists every postgres migration, versions must be unique and never reused.
+// Ids are object id hex strings, collated as bytes so that they sort by creation time.
+var sqlMigrations = []SqlMigration{
+	{Version: 1, Name: "create_users", Statements: []string{
+		`CREATE TABLE IF NOT EXISTS users (
+			id char(24) COLLATE "C" PRIMARY KEY,
+			name text NOT NULL DEFAULT '',
+			email text NOT NULL DEFAULT '',
+			password text NOT NULL DEFAULT '',
+			role text NOT NULL DEFAULT '',
+			version bigint NOT NULL DEFAULT 1,
+			created_at timestamptz NOT NULL,
+			updated_at timestamptz NOT NULL,
+			last_login_at timestamptz,
+			deleted_at timestamptz
+		)`,
+		// emails are unique regardless of case, like the collated mongo index
+		`CREATE UNIQUE INDEX IF NOT EXISTS users_email_unique ON users (lower(email)) WHERE email <> ''`,
+		`CREATE INDEX IF NOT EXISTS users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL`,
+	}},
+	{Version: 2, Name: "create_user_history", Statements: []string{
+		`CREATE TABLE IF NOT EXISTS user_history (
+			id char(24) COLLATE "C" PRIMARY KEY,
+			user_id char(24) COLLATE "C" NOT NULL,
+			actor_id char(24) COLLATE "C",
+			action text NOT NULL,
+			changes jsonb,
+			created_at timestamptz NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS user_history_user_id_newest ON user_history (user_id, id DESC)`,
+	}},
+	{Version: 3, Name: "create_tokens", Statements: []string{
+		`CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id char(24) COLLATE "C" PRIMARY KEY,
+			user_id char(24) COLLATE "C" NOT NULL,
+			family_id char(24) COLLATE "C" NOT NULL,
+			hash text NOT NULL,
+			expires_at timestamptz NOT NULL,
+			rotated_at timestamptz,
+			revoked boolean NOT NULL DEFAULT false
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS refresh_tokens_hash ON refresh_tokens (hash)`,
+		`CREATE INDEX IF NOT EXISTS refresh_tokens_user_id ON refresh_tokens (user_id)`,
+		`CREATE INDEX IF NOT EXISTS refresh_tokens_family_id ON refresh_tokens (family_id)`,
+		`CREATE TABLE IF NOT EXISTS revoked_tokens (
+			id char(24) COLLATE "C" PRIMARY KEY,
+			jti text,
+			user_id char(24) COLLATE "C" NOT NULL,
+			expires_at timestamptz,
+			revoked_at timestamptz NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS revoked_tokens_jti ON revoked_tokens (jti)`,
+		// a single row per user revokes every token issued before it
+		`CREATE UNIQUE INDEX IF NOT EXISTS revoked_tokens_user_unique ON revoked_tokens (user_id) WHERE jti IS NULL`,
+	}},
+	// expired tokens are purged on the purge interval, see RevokedTokenInterface.PurgeExpired
+	{Version: 4, Name: "index_revoked_tokens_expires_at", Statements: []string{
+		`CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at ON revoked_tokens (expires_at) WHERE jti IS NOT NULL`,
+	}},
+	// soft deleted users no longer hold their email, so it can be registered again
+	{Version: 5, Name: "exclude_deleted_users_from_email_unique", Statements: []string{
+		`DROP INDEX IF EXISTS users_email_unique`,
+		`CREATE UNIQUE INDEX users_email_unique ON users (lower(email)) WHERE email <> '' AND deleted_at IS NULL`,
+	}},
+	{Version: 6, Name: "add_users_password_reset_required", Statements: []string{
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required boolean NOT NULL DEFAULT false`,
+	}},
+}
@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOtlp   = "otlp"
+)
+
+// Config selects where spans are exported to and which share of new traces is sampled
+type Config struct {
+	Exporter    string
+	Endpoint    string
+	SampleRatio float64
+}
+
+// ConfigFromEnv reads TRACE_EXPORTER, TRACE_OTLP_ENDPOINT and TRACE_SAMPLE_RATIO,
+// tracing is off unless an exporter is set and every trace is sampled unless a ratio is set
+func ConfigFromEnv() (Config, error) {
+	trace := Config{
+		Exporter:    os.Getenv("TRACE_EXPORTER"),
+		Endpoint:    os.Getenv("TRACE_OTLP_ENDPOINT"),
+		SampleRatio: 1,
+	}
+
+	switch trace.Exporter {
+	case "":
+		trace.Exporter = ExporterNone
+	case ExporterNone, ExporterStdout, ExporterOtlp:
+	default:
+		return trace, fmt.Errorf("TRACE_EXPORTER must be %v, %v or %v", ExporterNone, ExporterStdout, ExporterOtlp)
+	}
+
+	// a collector running next to the service
+	if trace.Endpoint == "" {
+		trace.Endpoint = "localhost:4317"
+	}
+
+	if os.Getenv("TRACE_SAMPLE_RATIO") != "" {
+		ratio, err := strconv.ParseFloat(os.Getenv("TRACE_SAMPLE_RATIO"), 64)
+		if err != nil {
+			return trace, err
+		}
+		if ratio < 0 || ratio > 1 {
+			return trace, fmt.Errorf("TRACE_SAMPLE_RATIO must be between 0 and 1, got %v", ratio)
+		}
+		trace.SampleRatio = ratio
+	}
+
+	return trace, nil
+}
+
+// Init installs the global tracer provider and the W3C trace context propagator,
+// the provider has to be shut down on exit to flush spans that are not exported yet
+func Init(cfg Config, service string) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(service)))
+	if err != nil {
+		return nil, err
+	}
+
+	// a trace started upstream keeps the sampling decision of its caller
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+
+	switch cfg.Exporter {
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	case ExporterOtlp:
+		exporter, err := otlptracegrpc.New(context.Background(), otlptracegrpc.WithEndpoint(cfg.Endpoint), otlptracegrpc.WithInsecure())
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider, nil
+}
@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type actorContextKey struct{}
+
+// WithActor returns a context carrying the user acting on the request, it is recorded in user history
+func WithActor(ctx context.Context, actorId primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actorId)
+}
+
+// actorFromContext returns the acting user, empty for changes made by the system
+func actorFromContext(ctx context.Context) primitive.ObjectID {
+	actorId, _ := ctx.Value(actorContextKey{}).(primitive.ObjectID)
+	return actorId
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxBatchSize = 100
+
+// BatchGet returns users with the given ids in the order asked,
+// ids that are malformed or not found are reported as failures
+func (u *user) BatchGet(ctx context.Context, ids []string) (entity.UserBatchGet, error) {
+	batch := entity.UserBatchGet{Users: []entity.User{}}
+
+	objectIds, failures, err := batchIds(ids)
+	if err != nil {
+		return batch, err
+	}
+	batch.Failures = failures
+
+	if len(objectIds) == 0 {
+		return batch, nil
+	}
+
+	users, err := u.user.GetMany(ctx, objectIds)
+	if err != nil {
+		return batch, err
+	}
+
+	found := map[primitive.ObjectID]entity.User{}
+	for _, user := range users {
+		found[user.Id] = user
+	}
+
+	for _, id := range objectIds {
+		user, ok := found[id]
+		if !ok {
+			batch.Failures = append(batch.Failures, notFoundFailure(id))
+			continue
+		}
+
+		batch.Users = append(batch.Users, withDefaults(user))
+	}
+
+	return batch, nil
+}
+
+// BatchDelete soft deletes users with the given ids regardless of their version,
+// ids that are malformed or not found are reported as failures
+func (u *user) BatchDelete(ctx context.Context, ids []string) (entity.UserBatchDelete, error) {
+	batch := entity.UserBatchDelete{DeletedIds: []string{}}
+
+	objectIds, failures, err := batchIds(ids)
+	if err != nil {
+		return batch, err
+	}
+	batch.Failures = failures
+
+	if len(objectIds) == 0 {
+		return batch, nil
+	}
+
+	deleted, err := u.user.DeleteMany(ctx, objectIds)
+	if err != nil {
+		// users deleted before the failure must lose their sessions all the same
+		for _, id := range deleted {
+			u.afterDelete(ctx, id)
+		}
+		return batch, err
+	}
+
+	isDeleted := map[primitive.ObjectID]bool{}
+	for _, id := range deleted {
+		isDeleted[id] = true
+	}
+
+	for _, id := range objectIds {
+		if !isDeleted[id] {
+			batch.Failures = append(batch.Failures, notFoundFailure(id))
+			continue
+		}
+
+		batch.DeletedIds = append(batch.DeletedIds, id.Hex())
+		u.afterDelete(ctx, id)
+	}
+
+	return batch, nil
+}
+
+// afterDelete records the deletion of user, whose tokens must stop working right away instead of at expiry.
+// The user is deleted already, so a failure is logged and does not stop the rest of the batch
+func (u *user) afterDelete(ctx context.Context, id primitive.ObjectID) {
+	u.record(ctx, id, entity.UserActionDelete, nil)
+
+	if err := u.token.RevokeUser(ctx, id); err != nil {
+		u.logger.WithContext(ctx).Errorf("failed to revoke tokens of deleted user %v: %v", id.Hex(), err)
+	}
+}
+
+// batchIds parses ids of a batch keeping their order, repeated ids are only kept once
+// and malformed ones are returned as failures
+func batchIds(ids []string) ([]primitive.ObjectID, []entity.UserBatchFailure, error) {
+	switch {
+	case len(ids) == 0:
+		return nil, nil, errors.NewFieldError("Ids", "must not be empty")
+	case len(ids) > maxBatchSize:
+		return nil, nil, errors.NewFieldError("Ids", fmt.Sprintf("must have at most %v ids", maxBatchSize))
+	}
+
+	objectIds := []primitive.ObjectID{}
+	failures := []entity.UserBatchFailure{}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		objectId, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			failures = append(failures, entity.UserBatchFailure{Id: id, Code: entity.UserBatchInvalidId, Reason: err.Error()})
+			continue
+		}
+
+		objectIds = append(objectIds, objectId)
+	}
+
+	return objectIds, failures, nil
+}
+
+func notFoundFailure(id primitive.ObjectID) entity.UserBatchFailure {
+	return entity.UserBatchFailure{Id: id.Hex(), Code: entity.UserBatchNotFound, Reason: errors.ErrNotFound.Error()}
+}
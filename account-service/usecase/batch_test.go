@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"account-service/domain"
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// failingDeleteMany deletes only the first users asked for, then fails as storage going away would
+type failingDeleteMany struct {
+	domain.UserInterface
+	deleted int
+}
+
+func (f *failingDeleteMany) DeleteMany(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	deleted, err := f.UserInterface.DeleteMany(ctx, ids[:f.deleted])
+	if err != nil {
+		return deleted, err
+	}
+
+	return deleted, fmt.Errorf("connection lost")
+}
+
+// failingRevokeUser fails to revoke the refresh tokens of one user
+type failingRevokeUser struct {
+	domain.RefreshTokenInterface
+	userId primitive.ObjectID
+}
+
+func (f *failingRevokeUser) RevokeUser(ctx context.Context, userId primitive.ObjectID) error {
+	if userId == f.userId {
+		return fmt.Errorf("connection lost")
+	}
+
+	return f.RefreshTokenInterface.RevokeUser(ctx, userId)
+}
+
+func TestBatchDelete(t *testing.T) {
+	missing := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name string
+		// ids names the users to delete, index i stands for the i-th of three stored users
+		ids []any
+		// deleteMany is how many users storage deletes before failing, it does not fail when zero
+		deleteMany int
+		// revokeFails is the stored user whose sessions can not be revoked, -1 for none
+		revokeFails  int
+		wantDeleted  []int
+		wantFailures []entity.UserBatchFailure
+		wantErr      bool
+		// wantRevoked are the stored users whose sessions are revoked
+		wantRevoked []int
+	}{
+		{
+			name:        "found users are deleted and the rest reported",
+			ids:         []any{0, missing, "bad", 2, 0},
+			revokeFails: -1,
+			wantDeleted: []int{0, 2},
+			wantFailures: []entity.UserBatchFailure{
+				{Id: "bad", Code: entity.UserBatchInvalidId},
+				{Id: missing, Code: entity.UserBatchNotFound},
+			},
+			wantRevoked: []int{0, 2},
+		},
+		{
+			name:        "failed revocation does not stop the batch",
+			ids:         []any{0, 1, 2},
+			revokeFails: 0,
+			wantDeleted: []int{0, 1, 2},
+			wantRevoked: []int{1, 2},
+		},
+		{
+			name:        "users deleted before a storage failure are revoked",
+			ids:         []any{0, 1, 2},
+			deleteMany:  2,
+			revokeFails: -1,
+			wantErr:     true,
+			wantRevoked: []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			logger := testLogger()
+			dom := domain.InitMemory(logger)
+
+			stored := make([]primitive.ObjectID, 3)
+			for i := range stored {
+				user, err := dom.User.Create(ctx, entity.User{Name: "name", Email: fmt.Sprintf("user%v@example.com", i), Password: "hash"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				stored[i] = user.Id
+			}
+
+			if tt.deleteMany > 0 {
+				dom.User = &failingDeleteMany{UserInterface: dom.User, deleted: tt.deleteMany}
+			}
+			if tt.revokeFails >= 0 {
+				dom.RefreshToken = &failingRevokeUser{RefreshTokenInterface: dom.RefreshToken, userId: stored[tt.revokeFails]}
+			}
+			uc := Init(testConfig(time.Hour), logger, dom)
+
+			ids := []string{}
+			for _, id := range tt.ids {
+				if i, ok := id.(int); ok {
+					id = stored[i].Hex()
+				}
+				ids = append(ids, id.(string))
+			}
+
+			// a token issued a minute ago tells whether sessions of a user were revoked
+			issuedAt := time.Now().Add(-time.Minute)
+
+			batch, err := uc.User.BatchDelete(ctx, ids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				wantDeleted := []string{}
+				for _, i := range tt.wantDeleted {
+					wantDeleted = append(wantDeleted, stored[i].Hex())
+				}
+				if !reflect.DeepEqual(batch.DeletedIds, wantDeleted) {
+					t.Fatalf("got deleted %v, want %v", batch.DeletedIds, wantDeleted)
+				}
+
+				if len(batch.Failures) != len(tt.wantFailures) {
+					t.Fatalf("got failures %+v, want %+v", batch.Failures, tt.wantFailures)
+				}
+				for i, failure := range batch.Failures {
+					if failure.Id != tt.wantFailures[i].Id || failure.Code != tt.wantFailures[i].Code || failure.Reason == "" {
+						t.Fatalf("got failures %+v, want %+v", batch.Failures, tt.wantFailures)
+					}
+				}
+			}
+
+			for i, id := range stored {
+				revoked, err := uc.Token.IsRevoked(ctx, entity.RevokedToken{Jti: "jti", UserId: id, IssuedAt: issuedAt})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := containsInt(tt.wantRevoked, i); revoked != want {
+					t.Fatalf("user %v: got revoked %v, want %v", i, revoked, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchIds(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []string
+		wantErr bool
+	}{
+		{"empty", []string{}, true},
+		{"at most a batch", strings.Split(strings.Repeat(primitive.NewObjectID().Hex()+",", maxBatchSize-1)+primitive.NewObjectID().Hex(), ","), false},
+		{"more than a batch", strings.Split(strings.Repeat("id,", maxBatchSize)+"id", ","), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := batchIds(tt.ids)
+			if tt.wantErr && !errors.Is(err, errors.ErrBadRequest) {
+				t.Fatalf("got error %v, want a bad request", err)
+			} else if !tt.wantErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
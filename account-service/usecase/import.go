@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"fmt"
+	"net/mail"
+)
+
+const (
+	// importBatchSize is how many users are inserted at once
+	importBatchSize = 100
+	// maxImportRows bounds the report, which holds a result per row
+	maxImportRows = 10000
+)
+
+// Import creates users from rows returned by next until it reports no more rows.
+// All rows are read before anything is created, so a file over the row limit creates no user.
+// Rows are validated one by one and inserted in batches, a failing row does not stop the others.
+// On a dry run nothing is created, rows that would be created are reported valid.
+func (u *user) Import(ctx context.Context, dryRun bool, next func() (entity.UserImportRow, bool, error)) (entity.UserImportReport, error) {
+	report := entity.UserImportReport{DryRun: dryRun, Results: []entity.UserImportResult{}}
+
+	rows, err := readImportRows(next)
+	if err != nil {
+		return report, err
+	}
+
+	// rows repeating an email of an earlier row are duplicates even before anything is stored
+	seen := map[string]int{}
+	batch := []entity.UserImportRow{}
+
+	for _, row := range rows {
+		row.User = withDefaults(row.User)
+		row.User.Email = normalizeEmail(row.User.Email)
+		// files carry no credentials, an admin sets the password of an imported user
+		row.User.Password = ""
+		row.User.PasswordResetRequired = true
+
+		if reason := validateImportUser(row.User); reason != "" {
+			report.Add(entity.UserImportResult{Row: row.Row, Status: entity.UserImportInvalid, Email: row.User.Email, Reason: reason})
+			continue
+		}
+
+		if earlier, ok := seen[row.User.Email]; ok {
+			report.Add(entity.UserImportResult{Row: row.Row, Status: entity.UserImportDuplicate, Email: row.User.Email,
+				Reason: fmt.Sprintf("email already appears at row %v", earlier)})
+			continue
+		}
+		seen[row.User.Email] = row.Row
+
+		batch = append(batch, row)
+		if len(batch) == importBatchSize {
+			u.importBatch(ctx, &report, batch)
+			batch = batch[:0]
+		}
+	}
+	u.importBatch(ctx, &report, batch)
+
+	return report, nil
+}
+
+// readImportRows returns all rows of next, failing once there are more than maxImportRows
+func readImportRows(next func() (entity.UserImportRow, bool, error)) ([]entity.UserImportRow, error) {
+	rows := []entity.UserImportRow{}
+
+	for {
+		row, ok, err := next()
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return rows, nil
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, errors.NewFieldError("Row", fmt.Sprintf("at most %v rows can be imported at once", maxImportRows))
+		}
+		rows = append(rows, row)
+	}
+}
+
+func (u *user) importBatch(ctx context.Context, report *entity.UserImportReport, batch []entity.UserImportRow) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if report.DryRun {
+		u.checkBatch(ctx, report, batch)
+		return
+	}
+
+	users := make([]entity.User, len(batch))
+	for i, row := range batch {
+		users[i] = row.User
+	}
+
+	created, errs := u.user.CreateMany(ctx, users)
+	for i, row := range batch {
+		result := entity.UserImportResult{Row: row.Row, Status: entity.UserImportCreated, Email: row.User.Email}
+
+		switch {
+		case errs[i] == nil:
+			result.Id = created[i].Id.Hex()
+			u.record(ctx, created[i].Id, entity.UserActionCreate, diffUser(entity.User{}, created[i]))
+		case errors.Is(errs[i], errors.ErrDuplicatedKey):
+			result.Status, result.Reason = entity.UserImportDuplicate, "email is already registered"
+		default:
+			u.logger.WithContext(ctx).Errorf("failed to import row %v: %v", row.Row, errs[i])
+			result.Status, result.Reason = entity.UserImportInvalid, "failed to create user"
+		}
+		report.Add(result)
+	}
+}
+
+// checkBatch reports which rows of batch would be created, looking up their emails at once
+func (u *user) checkBatch(ctx context.Context, report *entity.UserImportReport, batch []entity.UserImportRow) {
+	emails := make([]string, len(batch))
+	for i, row := range batch {
+		emails[i] = row.User.Email
+	}
+
+	registered := map[string]bool{}
+	found, err := u.user.GetManyByEmail(ctx, emails)
+	for _, user := range found {
+		registered[normalizeEmail(user.Email)] = true
+	}
+
+	for _, row := range batch {
+		result := entity.UserImportResult{Row: row.Row, Status: entity.UserImportValid, Email: row.User.Email}
+
+		switch {
+		case err != nil:
+			u.logger.WithContext(ctx).Errorf("failed to check row %v: %v", row.Row, err)
+			result.Status, result.Reason = entity.UserImportInvalid, "failed to check user"
+		case registered[row.User.Email]:
+			result.Status, result.Reason = entity.UserImportDuplicate, "email is already registered"
+		}
+		report.Add(result)
+	}
+}
+
+// validateImportUser returns why user can not be imported, empty when it can
+func validateImportUser(user entity.User) string {
+	switch {
+	case user.Name == "":
+		return "name must not be empty"
+	case user.Email == "":
+		return "email must not be empty"
+	case !validRole(user.Role):
+		return "role must be admin or user"
+	}
+
+	if address, err := mail.ParseAddress(user.Email); err != nil || address.Address != user.Email {
+		return "email is not a valid address"
+	}
+
+	return ""
+}
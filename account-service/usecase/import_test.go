@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// importRows returns a next function handing out rows one by one, then err if set
+func importRows(rows []entity.UserImportRow, err error) func() (entity.UserImportRow, bool, error) {
+	i := 0
+	return func() (entity.UserImportRow, bool, error) {
+		if i == len(rows) {
+			return entity.UserImportRow{}, false, err
+		}
+		i++
+		return rows[i-1], true, nil
+	}
+}
+
+func importRow(row int, name, email, role string) entity.UserImportRow {
+	return entity.UserImportRow{Row: row, User: entity.User{Name: name, Email: email, Role: role, Password: "ignored"}}
+}
+
+func TestImport(t *testing.T) {
+	manyRows := make([]entity.UserImportRow, maxImportRows+1)
+	for i := range manyRows {
+		manyRows[i] = importRow(i+1, "name", fmt.Sprintf("user%v@example.com", i), "")
+	}
+
+	mixed := []entity.UserImportRow{
+		importRow(1, "jane", " Jane@Example.com ", ""),
+		importRow(2, "", "nameless@example.com", ""),
+		importRow(3, "bad", "not an email", ""),
+		importRow(4, "root", "root@example.com", "root"),
+		importRow(5, "jane again", "jane@example.com", ""),
+		importRow(6, "taken", "registered@example.com", ""),
+		importRow(7, "john", "john@example.com", entity.RoleAdmin),
+	}
+
+	tests := []struct {
+		name    string
+		dryRun  bool
+		rows    []entity.UserImportRow
+		nextErr error
+		// want is the status of each row in order, nil when the import fails
+		want        []string
+		wantErr     error
+		wantCreated []string
+	}{
+		{
+			name: "rows are reported one by one",
+			rows: mixed,
+			want: []string{
+				entity.UserImportCreated, entity.UserImportInvalid, entity.UserImportInvalid, entity.UserImportInvalid,
+				entity.UserImportDuplicate, entity.UserImportDuplicate, entity.UserImportCreated,
+			},
+			wantCreated: []string{"jane@example.com", "john@example.com"},
+		},
+		{
+			name:   "dry run creates nothing",
+			dryRun: true,
+			rows:   mixed,
+			want: []string{
+				entity.UserImportValid, entity.UserImportInvalid, entity.UserImportInvalid, entity.UserImportInvalid,
+				entity.UserImportDuplicate, entity.UserImportDuplicate, entity.UserImportValid,
+			},
+		},
+		{
+			name:    "too many rows create nothing",
+			rows:    manyRows,
+			wantErr: errors.ErrBadRequest,
+		},
+		{
+			name:    "failing stream creates nothing",
+			rows:    mixed,
+			nextErr: fmt.Errorf("stream broken"),
+			wantErr: fmt.Errorf("stream broken"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, dom := initTestUsecases(t, time.Hour)
+			ctx := context.Background()
+
+			if _, err := dom.User.Create(ctx, entity.User{Name: "registered", Email: "registered@example.com", Password: "hash"}); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := uc.User.Import(ctx, tt.dryRun, importRows(tt.rows, tt.nextErr))
+			if tt.wantErr != nil {
+				if err == nil || (!errors.Is(err, tt.wantErr) && err.Error() != tt.wantErr.Error()) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(report.Results) != len(tt.want) {
+				t.Fatalf("got %v results, want %v: %+v", len(report.Results), len(tt.want), report.Results)
+			}
+			// invalid rows are reported right away and the others once their batch is stored, so order is by row
+			byRow := map[int]entity.UserImportResult{}
+			counts := map[string]int{}
+			for _, result := range report.Results {
+				byRow[result.Row] = result
+				if result.Status != entity.UserImportCreated && result.Status != entity.UserImportValid && result.Reason == "" {
+					t.Fatalf("row %v is %v without a reason", result.Row, result.Status)
+				}
+				counts[result.Status]++
+			}
+			for i, status := range tt.want {
+				if result := byRow[tt.rows[i].Row]; result.Status != status {
+					t.Fatalf("got row %v %v (%v), want %v", tt.rows[i].Row, result.Status, result.Reason, status)
+				}
+			}
+			if report.Created != counts[entity.UserImportCreated]+counts[entity.UserImportValid] ||
+				report.Duplicates != counts[entity.UserImportDuplicate] || report.Invalid != counts[entity.UserImportInvalid] {
+				t.Fatalf("got counts %v created, %v duplicates and %v invalid, want %v", report.Created, report.Duplicates, report.Invalid, counts)
+			}
+
+			emails := []string{"registered@example.com"}
+			for _, row := range tt.rows {
+				emails = append(emails, normalizeEmail(row.User.Email))
+			}
+			stored, err := dom.User.GetManyByEmail(ctx, emails)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != len(tt.wantCreated)+1 {
+				t.Fatalf("got %v users stored, want %v imported and the registered one", len(stored), tt.wantCreated)
+			}
+
+			for _, user := range stored {
+				if user.Email == "registered@example.com" {
+					continue
+				}
+				// files carry no credentials, imported users must set a password before logging in
+				if user.Password != "" || !user.PasswordResetRequired {
+					t.Fatalf("got imported user %v with password %q and reset required %v", user.Email, user.Password, user.PasswordResetRequired)
+				}
+			}
+		})
+	}
+}
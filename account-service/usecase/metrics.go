@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	registrations = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "account_registrations_total",
+		Help: "Users created by registration or by an admin, imported users are not counted.",
+	})
+	logins = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "account_logins_total",
+		Help: "Credentials verified successfully.",
+	})
+	failedLogins = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "account_failed_logins_total",
+		Help: "Credentials rejected because of an unknown email or a wrong password.",
+	})
+)
@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"account-service/config"
+	"account-service/domain"
+	"account-service/entity"
+	"account-service/errors"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type token struct {
+	cfg          *config.Value
+	logger       *logrus.Logger
+	refreshToken domain.RefreshTokenInterface
+	revokedToken domain.RevokedTokenInterface
+}
+
+type TokenInterface interface {
+	Issue(ctx context.Context, userId primitive.ObjectID) (entity.RefreshToken, error)
+	Check(ctx context.Context, refreshToken string) (entity.RefreshToken, error)
+	Rotate(ctx context.Context, refreshToken string) (entity.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
+	RevokeAccessToken(ctx context.Context, accessToken entity.RevokedToken) error
+	RevokeUser(ctx context.Context, userId primitive.ObjectID) error
+	IsRevoked(ctx context.Context, accessToken entity.RevokedToken) (bool, error)
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// initToken creates token usecase
+func initToken(cfg *config.Value, logger *logrus.Logger, refreshTokenDom domain.RefreshTokenInterface, revokedTokenDom domain.RevokedTokenInterface) TokenInterface {
+	return &token{
+		cfg:          cfg,
+		logger:       logger,
+		refreshToken: refreshTokenDom,
+		revokedToken: revokedTokenDom,
+	}
+}
+
+// Issue starts a new refresh token family for user
+func (t *token) Issue(ctx context.Context, userId primitive.ObjectID) (entity.RefreshToken, error) {
+	return t.issue(ctx, userId, primitive.NewObjectID())
+}
+
+// Check returns refresh token if it can be rotated, without rotating it,
+// so that callers can do the work that may fail before the token is used up
+func (t *token) Check(ctx context.Context, refreshToken string) (entity.RefreshToken, error) {
+	return t.current(ctx, refreshToken)
+}
+
+// Rotate exchanges refresh token with a new one from the same family
+func (t *token) Rotate(ctx context.Context, refreshToken string) (entity.RefreshToken, error) {
+	current, err := t.current(ctx, refreshToken)
+	if err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	if err := t.refreshToken.MarkRotated(ctx, current); errors.Is(err, errors.ErrNotFound) {
+		return entity.RefreshToken{}, t.revokeFamily(ctx, current)
+	} else if err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	// marking comes first so that concurrent rotations can not both win, but a failed issue hands the
+	// token back, otherwise the retry would look like reuse and log the user out over a transient error
+	newToken, err := t.issue(ctx, current.UserId, current.FamilyId)
+	if err != nil {
+		if undoErr := t.refreshToken.UnmarkRotated(context.WithoutCancel(ctx), current); undoErr != nil {
+			t.logger.WithContext(ctx).Errorf("failed to unmark rotated refresh token %v: %v", current.Id.Hex(), undoErr)
+		}
+		return entity.RefreshToken{}, err
+	}
+
+	return newToken, nil
+}
+
+// RevokeRefreshToken revokes refresh token along with the rest of its family
+func (t *token) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	current, err := t.refreshToken.Get(ctx, entity.RefreshToken{Hash: hashToken(refreshToken)})
+	if errors.Is(err, errors.ErrNotFound) {
+		return errors.ErrUnauthorized
+	} else if err != nil {
+		return err
+	}
+
+	return t.refreshToken.RevokeFamily(ctx, current.FamilyId)
+}
+
+// RevokeAccessToken adds access token to revocation list until it expires
+func (t *token) RevokeAccessToken(ctx context.Context, accessToken entity.RevokedToken) error {
+	if accessToken.Jti == "" {
+		return errors.NewFieldError("Jti", "must not be empty")
+	}
+
+	return t.revokedToken.Create(ctx, accessToken)
+}
+
+// RevokeUser revokes every session of user, both access and refresh tokens
+func (t *token) RevokeUser(ctx context.Context, userId primitive.ObjectID) error {
+	if err := t.refreshToken.RevokeUser(ctx, userId); err != nil {
+		return err
+	}
+
+	return t.revokedToken.RevokeUser(ctx, userId)
+}
+
+// IsRevoked checks access token against revocation list
+func (t *token) IsRevoked(ctx context.Context, accessToken entity.RevokedToken) (bool, error) {
+	return t.revokedToken.IsRevoked(ctx, accessToken)
+}
+
+// PurgeExpired drops revoked access tokens that have expired and so can not be presented anymore
+func (t *token) PurgeExpired(ctx context.Context) (int64, error) {
+	return t.revokedToken.PurgeExpired(ctx, time.Now())
+}
+
+// current returns the stored refresh token if it is still usable,
+// a token that has been rotated already revokes its whole family
+func (t *token) current(ctx context.Context, refreshToken string) (entity.RefreshToken, error) {
+	current, err := t.refreshToken.Get(ctx, entity.RefreshToken{Hash: hashToken(refreshToken)})
+	if errors.Is(err, errors.ErrNotFound) {
+		return entity.RefreshToken{}, errors.ErrUnauthorized
+	} else if err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	if current.Revoked || time.Now().After(current.ExpiresAt) {
+		return entity.RefreshToken{}, errors.ErrUnauthorized
+	}
+
+	// a refresh token can only be used once, seeing it again means it has leaked
+	if !current.RotatedAt.IsZero() {
+		return entity.RefreshToken{}, t.revokeFamily(ctx, current)
+	}
+
+	return current, nil
+}
+
+func (t *token) issue(ctx context.Context, userId, familyId primitive.ObjectID) (entity.RefreshToken, error) {
+	plain, err := generateToken()
+	if err != nil {
+		return entity.RefreshToken{}, err
+	}
+
+	newToken, err := t.refreshToken.Create(ctx, entity.RefreshToken{
+		UserId:    userId,
+		FamilyId:  familyId,
+		Hash:      hashToken(plain),
+		ExpiresAt: time.Now().Add(t.cfg.Auth.RefreshTokenTTL),
+	})
+	if err != nil {
+		return newToken, err
+	}
+	newToken.Token = plain
+
+	return newToken, nil
+}
+
+func (t *token) revokeFamily(ctx context.Context, reused entity.RefreshToken) error {
+	t.logger.WithContext(ctx).Warnf("refresh token reuse detected for user %v, revoking token family %v", reused.UserId.Hex(), reused.FamilyId.Hex())
+
+	if err := t.refreshToken.RevokeFamily(ctx, reused.FamilyId); err != nil {
+		return err
+	}
+
+	return errors.ErrUnauthorized
+}
+
+// generateToken returns random opaque token safe to be used in url and json
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the digest stored in place of the plain token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
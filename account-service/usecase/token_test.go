@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"account-service/errors"
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		// before runs ahead of the rotation, returning a successor of issued when it creates one
+		before func(t *testing.T, tok TokenInterface, issued string) string
+		// token is rotated instead of issued when set
+		token string
+		// check only checks the token instead of rotating it
+		check            bool
+		wantErr          error
+		successorRevoked bool
+	}{
+		{
+			name: "fresh token",
+		},
+		{
+			name:    "unknown token",
+			token:   "unknown",
+			wantErr: errors.ErrUnauthorized,
+		},
+		{
+			name:    "expired token",
+			ttl:     -time.Minute,
+			wantErr: errors.ErrUnauthorized,
+		},
+		{
+			name: "revoked token",
+			before: func(t *testing.T, tok TokenInterface, issued string) string {
+				if err := tok.RevokeRefreshToken(context.Background(), issued); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			},
+			wantErr: errors.ErrUnauthorized,
+		},
+		{
+			name:             "reused token revokes its family",
+			before:           rotate,
+			wantErr:          errors.ErrUnauthorized,
+			successorRevoked: true,
+		},
+		{
+			name:             "reuse seen by check revokes its family",
+			before:           rotate,
+			check:            true,
+			wantErr:          errors.ErrUnauthorized,
+			successorRevoked: true,
+		},
+		{
+			name: "checked token can still be rotated",
+			before: func(t *testing.T, tok TokenInterface, issued string) string {
+				if _, err := tok.Check(context.Background(), issued); err != nil {
+					t.Fatal(err)
+				}
+				return ""
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl := tt.ttl
+			if ttl == 0 {
+				ttl = time.Hour
+			}
+			uc, _ := initTestUsecases(t, ttl)
+			ctx := context.Background()
+			userId := primitive.NewObjectID()
+
+			issued, err := uc.Token.Issue(ctx, userId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// another session of the same user must outlive whatever happens to this family
+			other, err := uc.Token.Issue(ctx, userId)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			successor := ""
+			if tt.before != nil {
+				successor = tt.before(t, uc.Token, issued.Token)
+			}
+
+			token := issued.Token
+			if tt.token != "" {
+				token = tt.token
+			}
+
+			if tt.check {
+				_, err = uc.Token.Check(ctx, token)
+			} else {
+				var rotated string
+				rotated, err = rotateToken(uc.Token, token)
+				if err == nil && (rotated == "" || rotated == token) {
+					t.Fatalf("got token %q, want a new one", rotated)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if successor != "" {
+				_, err := uc.Token.Rotate(ctx, successor)
+				if revoked := errors.Is(err, errors.ErrUnauthorized); revoked != tt.successorRevoked {
+					t.Fatalf("got successor revoked %v (%v), want %v", revoked, err, tt.successorRevoked)
+				}
+			}
+
+			if ttl > 0 {
+				if _, err := uc.Token.Rotate(ctx, other.Token); err != nil {
+					t.Fatalf("other family: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// rotate rotates issued ahead of the test, returning its successor
+func rotate(t *testing.T, tok TokenInterface, issued string) string {
+	t.Helper()
+
+	successor, err := rotateToken(tok, issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return successor
+}
+
+func rotateToken(tok TokenInterface, token string) (string, error) {
+	rotated, err := tok.Rotate(context.Background(), token)
+	return rotated.Token, err
+}
@@ -8,11 +8,15 @@ import (
 )
 
 type Usecases struct {
-	User UserInterface
+	User  UserInterface
+	Token TokenInterface
 }
 
 func Init(cfg *config.Value, logger *logrus.Logger, dom *domain.Domains) *Usecases {
+	token := initToken(cfg, logger, dom.RefreshToken, dom.RevokedToken)
+
 	return &Usecases{
-		User: initUser(cfg, dom.User),
+		User:  initUser(cfg, logger, dom.User, dom.UserHistory, token),
+		Token: token,
 	}
 }
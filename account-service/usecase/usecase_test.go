@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"account-service/config"
+	"account-service/domain"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return logger
+}
+
+func testConfig(refreshTokenTTL time.Duration) *config.Value {
+	return &config.Value{
+		Auth:  config.Auth{RefreshTokenTTL: refreshTokenTTL},
+		Purge: config.Purge{Retention: time.Hour, Interval: time.Hour},
+	}
+}
+
+// initTestUsecases creates usecases over empty memory storage
+func initTestUsecases(t *testing.T, refreshTokenTTL time.Duration) (*Usecases, *domain.Domains) {
+	t.Helper()
+
+	logger := testLogger()
+	dom := domain.InitMemory(logger)
+
+	return Init(testConfig(refreshTokenTTL), logger, dom), dom
+}
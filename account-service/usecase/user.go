@@ -4,46 +4,424 @@ import (
 	"account-service/config"
 	"account-service/domain"
 	"account-service/entity"
+	"account-service/errors"
 	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
 )
 
 type user struct {
-	cfg  *config.Value
-	user domain.UserInterface
+	cfg     *config.Value
+	logger  *logrus.Logger
+	user    domain.UserInterface
+	history domain.UserHistoryInterface
+	token   TokenInterface
 }
 
 type UserInterface interface {
 	List(ctx context.Context) ([]entity.User, error)
+	ListPage(ctx context.Context, query entity.UserQuery) (entity.UserPage, error)
+	Stream(ctx context.Context, fn func(entity.User) error) error
 	Get(ctx context.Context, filter entity.User) (entity.User, error)
+	BatchGet(ctx context.Context, ids []string) (entity.UserBatchGet, error)
 	Create(ctx context.Context, user entity.User) (entity.User, error)
-	Update(ctx context.Context, user entity.User) (entity.User, error)
+	Import(ctx context.Context, dryRun bool, next func() (entity.UserImportRow, bool, error)) (entity.UserImportReport, error)
+	Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error)
 	Delete(ctx context.Context, user entity.User) error
+	BatchDelete(ctx context.Context, ids []string) (entity.UserBatchDelete, error)
+	Restore(ctx context.Context, user entity.User) (entity.User, error)
+	Purge(ctx context.Context) (int64, error)
+	PurgeEvery(ctx context.Context, interval time.Duration)
+	EnsureAdmin(ctx context.Context, email string) error
+	VerifyCredentials(ctx context.Context, email, password string) (entity.User, error)
+	SetPassword(ctx context.Context, id primitive.ObjectID, password string) error
+	ChangePassword(ctx context.Context, id primitive.ObjectID, oldPassword, newPassword string) error
+	History(ctx context.Context, query entity.UserHistoryQuery) (entity.UserHistory, error)
 }
 
 // initUser creates user repository
-func initUser(cfg *config.Value, userDom domain.UserInterface) UserInterface {
+func initUser(cfg *config.Value, logger *logrus.Logger, userDom domain.UserInterface, historyDom domain.UserHistoryInterface, token TokenInterface) UserInterface {
 	return &user{
-		cfg:  cfg,
-		user: userDom,
+		cfg:     cfg,
+		logger:  logger,
+		user:    userDom,
+		history: historyDom,
+		token:   token,
 	}
 }
 
 func (u *user) List(ctx context.Context) ([]entity.User, error) {
-	return u.user.List(ctx)
+	users, err := u.user.List(ctx)
+	if err != nil {
+		return users, err
+	}
+
+	for i := range users {
+		users[i] = withDefaults(users[i])
+	}
+
+	return users, nil
+}
+
+func (u *user) ListPage(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	switch {
+	case query.Limit == 0:
+		query.Limit = defaultPageSize
+	case query.Limit < 0 || query.Limit > maxPageSize:
+		return entity.UserPage{}, errors.NewFieldError("PageSize", fmt.Sprintf("must be between 1 and %v", maxPageSize))
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = entity.UserSortCreated
+	case entity.UserSortCreated, entity.UserSortCreatedDesc, entity.UserSortName, entity.UserSortNameDesc, entity.UserSortEmail, entity.UserSortEmailDesc:
+	default:
+		return entity.UserPage{}, errors.NewFieldError("Sort", "must be one of created, name, email, optionally prefixed with -")
+	}
+
+	query.Email = normalizeEmail(query.Email)
+
+	page, err := u.user.ListPage(ctx, query)
+	if err != nil {
+		return page, err
+	}
+
+	for i := range page.Users {
+		page.Users[i] = withDefaults(page.Users[i])
+	}
+
+	return page, nil
+}
+
+// Stream calls fn with every user that is not soft deleted, stopping at the first error of fn
+func (u *user) Stream(ctx context.Context, fn func(entity.User) error) error {
+	return u.user.Stream(ctx, func(user entity.User) error {
+		return fn(withDefaults(user))
+	})
 }
 
 func (u *user) Get(ctx context.Context, filter entity.User) (entity.User, error) {
-	return u.user.Get(ctx, filter)
+	filter.Email = normalizeEmail(filter.Email)
+
+	user, err := u.user.Get(ctx, filter)
+	if err != nil {
+		return user, err
+	}
+
+	return withDefaults(user), nil
 }
 
 func (u *user) Create(ctx context.Context, user entity.User) (entity.User, error) {
-	return u.user.Create(ctx, user)
+	user = withDefaults(user)
+	user.Email = normalizeEmail(user.Email)
+	if !validRole(user.Role) {
+		return user, errors.NewFieldError("Role", "must be admin or user")
+	}
+
+	if user.Password != "" {
+		hashedPassword, err := hashPassword(user.Password)
+		if err != nil {
+			return user, err
+		}
+		user.Password = hashedPassword
+	}
+
+	newUser, err := u.user.Create(ctx, user)
+	if err != nil {
+		return newUser, err
+	}
+
+	u.record(ctx, newUser.Id, entity.UserActionCreate, diffUser(entity.User{}, newUser))
+	registrations.Inc()
+
+	return newUser, nil
 }
 
-func (u *user) Update(ctx context.Context, user entity.User) (entity.User, error) {
-	return u.user.Update(ctx, user)
+// Update updates fields of user, every non empty field when no field is given
+func (u *user) Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error) {
+	user.Email = normalizeEmail(user.Email)
+	for _, field := range fields {
+		switch field {
+		case entity.UserFieldName:
+		case entity.UserFieldEmail:
+			if user.Email == "" {
+				return user, errors.NewFieldError("Email", "must not be empty")
+			}
+		case entity.UserFieldRole:
+			if !validRole(user.Role) {
+				return user, errors.NewFieldError("Role", "must be admin or user")
+			}
+		default:
+			return user, errors.NewFieldError("UpdateMask", fmt.Sprintf("field %q can not be updated", field))
+		}
+	}
+
+	if len(fields) == 0 && user.Role != "" && !validRole(user.Role) {
+		return user, errors.NewFieldError("Role", "must be admin or user")
+	}
+
+	current, err := u.Get(ctx, entity.User{Id: user.Id})
+	if err != nil {
+		return user, err
+	}
+
+	newUser, err := u.user.Update(ctx, user, fields...)
+	if err != nil {
+		return newUser, err
+	}
+	newUser = withDefaults(newUser)
+
+	if changes := diffUser(current, newUser); len(changes) > 0 {
+		u.record(ctx, newUser.Id, entity.UserActionUpdate, changes)
+	}
+
+	// role is carried in access token claims, old tokens must not keep the old role
+	if newUser.Role != current.Role {
+		if err := u.token.RevokeUser(ctx, newUser.Id); err != nil {
+			return newUser, err
+		}
+	}
+
+	return newUser, nil
 }
 
 func (u *user) Delete(ctx context.Context, user entity.User) error {
-	return u.user.Delete(ctx, user)
+	if err := u.user.Delete(ctx, user); err != nil {
+		return err
+	}
+
+	u.record(ctx, user.Id, entity.UserActionDelete, nil)
+
+	// tokens of deleted user must stop working right away instead of at expiry
+	return u.token.RevokeUser(ctx, user.Id)
+}
+
+// Restore brings back soft deleted user, sessions revoked on delete stay revoked
+func (u *user) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	restored, err := u.user.Restore(ctx, user)
+	if err != nil {
+		return restored, err
+	}
+
+	u.record(ctx, restored.Id, entity.UserActionRestore, nil)
+
+	return withDefaults(restored), nil
+}
+
+// Purge hard deletes users soft deleted longer than the retention period
+func (u *user) Purge(ctx context.Context) (int64, error) {
+	return u.user.Purge(ctx, time.Now().Add(-u.cfg.Purge.Retention))
+}
+
+// PurgeEvery runs Purge on a fixed interval along with dropping expired revoked tokens,
+// it blocks until ctx is done so run it in its own goroutine
+func (u *user) PurgeEvery(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := u.Purge(ctx)
+		if err != nil {
+			u.logger.WithContext(ctx).Error(err)
+			continue
+		}
+
+		if purged > 0 {
+			u.logger.WithContext(ctx).Infof("purged %v deleted users", purged)
+		}
+
+		expired, err := u.token.PurgeExpired(ctx)
+		if err != nil {
+			u.logger.WithContext(ctx).Error(err)
+			continue
+		}
+
+		if expired > 0 {
+			u.logger.WithContext(ctx).Infof("purged %v expired revoked tokens", expired)
+		}
+	}
+}
+
+// EnsureAdmin grants admin role to user with given email, used to bootstrap the first admin
+func (u *user) EnsureAdmin(ctx context.Context, email string) error {
+	user, err := u.Get(ctx, entity.User{Email: email})
+	if errors.Is(err, errors.ErrNotFound) {
+		u.logger.WithContext(ctx).Warnf("admin user %v is not registered yet", email)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if user.Role == entity.RoleAdmin {
+		return nil
+	}
+
+	_, err = u.Update(ctx, entity.User{Id: user.Id, Role: entity.RoleAdmin}, entity.UserFieldRole)
+	return err
+}
+
+// VerifyCredentials returns user owning the email when password matches
+func (u *user) VerifyCredentials(ctx context.Context, email, password string) (entity.User, error) {
+	user, err := u.user.Get(ctx, entity.User{Email: normalizeEmail(email)})
+	if errors.Is(err, errors.ErrNotFound) {
+		failedLogins.Inc()
+		return entity.User{}, errors.ErrUnauthorized
+	} else if err != nil {
+		return entity.User{}, err
+	}
+
+	// users without password, e.g. created by admin or imported, can not login until one is set
+	if user.Password == "" || user.PasswordResetRequired || checkPasswordHash(user.Password, password) != nil {
+		failedLogins.Inc()
+		return entity.User{}, errors.ErrUnauthorized
+	}
+	logins.Inc()
+
+	// failing to record the login must not fail the login itself
+	user.LastLoginAt = time.Now()
+	if err := u.user.TouchLogin(ctx, user); err != nil {
+		u.logger.WithContext(ctx).Error(err)
+	}
+
+	return withDefaults(user), nil
+}
+
+// SetPassword replaces password of user, every session of the user is revoked
+func (u *user) SetPassword(ctx context.Context, id primitive.ObjectID, password string) error {
+	if password == "" {
+		return errors.NewFieldError("Password", "must not be empty")
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if _, err := u.user.Update(ctx, entity.User{Id: id, Password: hashedPassword}, entity.UserFieldPassword); err != nil {
+		return err
+	}
+
+	// password hashes are never written to history, only the fact it changed
+	u.record(ctx, id, entity.UserActionPassword, []entity.FieldChange{{Field: entity.UserFieldPassword}})
+
+	return u.token.RevokeUser(ctx, id)
+}
+
+// ChangePassword replaces password of user once old password is confirmed
+func (u *user) ChangePassword(ctx context.Context, id primitive.ObjectID, oldPassword, newPassword string) error {
+	user, err := u.user.Get(ctx, entity.User{Id: id})
+	if err != nil {
+		return err
+	}
+
+	if user.Password == "" || checkPasswordHash(user.Password, oldPassword) != nil {
+		return errors.ErrUnauthorized
+	}
+
+	return u.SetPassword(ctx, id, newPassword)
+}
+
+// History returns a page of changes made to user, newest first
+func (u *user) History(ctx context.Context, query entity.UserHistoryQuery) (entity.UserHistory, error) {
+	switch {
+	case query.Limit == 0:
+		query.Limit = defaultPageSize
+	case query.Limit < 0 || query.Limit > maxPageSize:
+		return entity.UserHistory{}, errors.NewFieldError("PageSize", fmt.Sprintf("must be between 1 and %v", maxPageSize))
+	}
+
+	return u.history.List(ctx, query)
+}
+
+// record writes a change of user to history, history is best effort
+// so failing to write it is logged rather than failing the change
+func (u *user) record(ctx context.Context, userId primitive.ObjectID, action string, changes []entity.FieldChange) {
+	change := entity.UserChange{
+		UserId:    userId,
+		ActorId:   actorFromContext(ctx),
+		Action:    action,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+
+	if err := u.history.Create(ctx, change); err != nil {
+		u.logger.WithContext(ctx).Errorf("failed to record %v of user %v: %v", action, userId.Hex(), err)
+	}
+}
+
+// diffUser lists fields whose value differs between old and new user
+func diffUser(old, new entity.User) []entity.FieldChange {
+	changes := []entity.FieldChange{}
+	for _, field := range []string{entity.UserFieldName, entity.UserFieldEmail, entity.UserFieldRole} {
+		oldValue, newValue := userField(old, field), userField(new, field)
+		if oldValue != newValue {
+			changes = append(changes, entity.FieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	return changes
+}
+
+func userField(user entity.User, field string) string {
+	switch field {
+	case entity.UserFieldName:
+		return user.Name
+	case entity.UserFieldEmail:
+		return user.Email
+	case entity.UserFieldRole:
+		return user.Role
+	default:
+		return ""
+	}
+}
+
+// withDefaults fills fields of users stored before those fields existed,
+// they are regular users at their first version, not updated since created
+func withDefaults(user entity.User) entity.User {
+	if user.Role == "" {
+		user.Role = entity.RoleUser
+	}
+
+	if user.Version == 0 {
+		user.Version = 1
+	}
+
+	// object id starts with its creation time
+	if user.CreatedAt.IsZero() && !user.Id.IsZero() {
+		user.CreatedAt = user.Id.Timestamp()
+	}
+
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = user.CreatedAt
+	}
+
+	return user
+}
+
+// normalizeEmail makes emails differing only in case or surrounding spaces equal
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func validRole(role string) bool {
+	return role == entity.RoleAdmin || role == entity.RoleUser
 }
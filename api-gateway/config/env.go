@@ -1,8 +1,11 @@
 package config
 
 import (
+	"account-service/tracing"
+	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,19 +15,28 @@ type Value struct {
 	Log        Log
 	Server     Server
 	GrpcServer Server
+	Trace      tracing.Config
 }
 
 type Auth struct {
-	SecretKey string
+	SigningAlg          string
+	KeysDir             string
+	KeysReloadInterval  time.Duration
+	KeyRotationInterval time.Duration
+	AccessTokenTTL      time.Duration
+	RevocationCacheTTL  time.Duration
 }
 
 type Server struct {
-	Base string
-	Port int
+	Base           string
+	Port           int
+	AdminPort      int
+	RequireIfMatch bool
 }
 
 type Log struct {
-	Level string
+	Level       string
+	RedactEmail bool
 }
 
 func InitEnv() (*Value, error) {
@@ -43,20 +55,101 @@ func InitEnv() (*Value, error) {
 		return nil, err
 	}
 
+	accessTokenTTL := 15 * time.Minute
+	if os.Getenv("AUTH_ACCESS_TOKEN_TTL") != "" {
+		accessTokenTTL, err = time.ParseDuration(os.Getenv("AUTH_ACCESS_TOKEN_TTL"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	revocationCacheTTL := 30 * time.Second
+	if os.Getenv("AUTH_REVOCATION_CACHE_TTL") != "" {
+		revocationCacheTTL, err = time.ParseDuration(os.Getenv("AUTH_REVOCATION_CACHE_TTL"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	requireIfMatch := false
+	if os.Getenv("SERVER_REQUIRE_IF_MATCH") != "" {
+		requireIfMatch, err = strconv.ParseBool(os.Getenv("SERVER_REQUIRE_IF_MATCH"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// secrets are always masked in logs, emails only when asked to
+	redactEmail := false
+	if os.Getenv("LOG_REDACT_EMAIL") != "" {
+		redactEmail, err = strconv.ParseBool(os.Getenv("LOG_REDACT_EMAIL"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	signingAlg := os.Getenv("AUTH_SIGNING_ALG")
+	if signingAlg != "RS256" && signingAlg != "ES256" {
+		return nil, fmt.Errorf("unsupported signing algorithm %q, use RS256 or ES256", signingAlg)
+	}
+
+	// instances sharing AUTH_KEYS_DIR pick up keys rotated by each other this often
+	keysReloadInterval := 30 * time.Second
+	if os.Getenv("AUTH_KEYS_RELOAD_INTERVAL") != "" {
+		keysReloadInterval, err = time.ParseDuration(os.Getenv("AUTH_KEYS_RELOAD_INTERVAL"))
+		if err != nil {
+			return nil, err
+		}
+		if keysReloadInterval <= 0 {
+			return nil, fmt.Errorf("AUTH_KEYS_RELOAD_INTERVAL must be positive, got %v", keysReloadInterval)
+		}
+	}
+
+	keyRotationInterval := 24 * time.Hour
+	if os.Getenv("AUTH_KEY_ROTATION_INTERVAL") != "" {
+		keyRotationInterval, err = time.ParseDuration(os.Getenv("AUTH_KEY_ROTATION_INTERVAL"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// metrics are served on their own listener, which is off unless a port is set
+	adminPort := 0
+	if os.Getenv("SERVER_ADMIN_PORT") != "" {
+		adminPort, err = strconv.Atoi(os.Getenv("SERVER_ADMIN_PORT"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	trace, err := tracing.ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Value{
 		Auth: Auth{
-			SecretKey: os.Getenv("AUTH_SECRETKEY"),
+			SigningAlg:          signingAlg,
+			KeysDir:             os.Getenv("AUTH_KEYS_DIR"),
+			KeysReloadInterval:  keysReloadInterval,
+			KeyRotationInterval: keyRotationInterval,
+			AccessTokenTTL:      accessTokenTTL,
+			RevocationCacheTTL:  revocationCacheTTL,
 		},
 		Log: Log{
-			Level: os.Getenv("LOG_LEVEL"),
+			Level:       os.Getenv("LOG_LEVEL"),
+			RedactEmail: redactEmail,
 		},
 		Server: Server{
-			Base: os.Getenv("SERVER_BASE"),
-			Port: port,
+			Base:           os.Getenv("SERVER_BASE"),
+			Port:           port,
+			AdminPort:      adminPort,
+			RequireIfMatch: requireIfMatch,
 		},
 		GrpcServer: Server{
 			Base: os.Getenv("GRPC_SERVER_BASE"),
 			Port: grpcPort,
 		},
+		Trace: trace,
 	}, nil
 }
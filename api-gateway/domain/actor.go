@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// actorMetadataKey carries id of the logged in user making the request to account-service
+const actorMetadataKey = "x-actor-id"
+
+// WithActor returns a context whose outgoing calls tell account-service which user is acting
+func WithActor(ctx context.Context, actorId string) context.Context {
+	if actorId == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, actorMetadataKey, actorId)
+}
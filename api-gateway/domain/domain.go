@@ -2,16 +2,19 @@ package domain
 
 import (
 	"account-service/grpc"
+	accountv1 "account-service/grpc/account/v1"
 
 	"github.com/sirupsen/logrus"
 )
 
 type Domains struct {
-	User UserInterface
+	User  UserInterface
+	Token TokenInterface
 }
 
-func Init(logger *logrus.Logger, userClient grpc.UserServiceClient) *Domains {
+func Init(logger *logrus.Logger, userClient accountv1.UserServiceClient, tokenClient grpc.TokenServiceClient) *Domains {
 	return &Domains{
-		User: initUser(logger, userClient),
+		User:  initUser(logger, userClient),
+		Token: initToken(logger, tokenClient),
 	}
 }
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"account-service/logging"
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIdMetadataKey carries id of the http request the call to account-service is made for
+const requestIdMetadataKey = "x-request-id"
+
+// RequestIdUnaryClientInterceptor forwards the request id of the context to account-service
+func RequestIdUnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(withRequestId(ctx), method, req, reply, cc, opts...)
+}
+
+// RequestIdStreamClientInterceptor forwards the request id of the context to account-service
+func RequestIdStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(withRequestId(ctx), desc, cc, method, opts...)
+}
+
+func withRequestId(ctx context.Context) context.Context {
+	requestId := logging.RequestIdFromContext(ctx)
+	if requestId == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, requestIdMetadataKey, requestId)
+}
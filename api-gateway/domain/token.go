@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"account-service/grpc"
+	"api-gateway/entity"
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type token struct {
+	logger      *logrus.Logger
+	tokenClient grpc.TokenServiceClient
+}
+
+type TokenInterface interface {
+	Issue(ctx context.Context, userId string) (entity.RefreshToken, error)
+	Check(ctx context.Context, refreshToken string) (entity.RefreshToken, error)
+	Rotate(ctx context.Context, refreshToken string) (entity.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
+	RevokeAccessToken(ctx context.Context, accessToken entity.AccessToken) error
+	RevokeUser(ctx context.Context, userId string) error
+	IsRevoked(ctx context.Context, accessToken entity.AccessToken) (bool, error)
+}
+
+// initToken creates token domain
+func initToken(logger *logrus.Logger, tokenClient grpc.TokenServiceClient) TokenInterface {
+	return &token{
+		logger:      logger,
+		tokenClient: tokenClient,
+	}
+}
+
+// Issue creates new refresh token for user
+func (t *token) Issue(ctx context.Context, userId string) (entity.RefreshToken, error) {
+	var newToken entity.RefreshToken
+	res, err := t.tokenClient.IssueRefreshToken(ctx, &grpc.RefreshToken{
+		UserId: userId,
+	})
+	if err != nil {
+		return newToken, err
+	}
+
+	newToken.ConvertFromProto(res)
+
+	return newToken, nil
+}
+
+// Check returns the user of refresh token without using it up
+func (t *token) Check(ctx context.Context, refreshToken string) (entity.RefreshToken, error) {
+	var current entity.RefreshToken
+	res, err := t.tokenClient.CheckRefreshToken(ctx, &grpc.RefreshToken{
+		Token: refreshToken,
+	})
+	if err != nil {
+		return current, err
+	}
+
+	current.ConvertFromProto(res)
+
+	return current, nil
+}
+
+// Rotate exchanges refresh token with a new one
+func (t *token) Rotate(ctx context.Context, refreshToken string) (entity.RefreshToken, error) {
+	var newToken entity.RefreshToken
+	res, err := t.tokenClient.RotateRefreshToken(ctx, &grpc.RefreshToken{
+		Token: refreshToken,
+	})
+	if err != nil {
+		return newToken, err
+	}
+
+	newToken.ConvertFromProto(res)
+
+	return newToken, nil
+}
+
+// RevokeRefreshToken revokes refresh token along with its family
+func (t *token) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	_, err := t.tokenClient.RevokeRefreshToken(ctx, &grpc.RefreshToken{
+		Token: refreshToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAccessToken adds access token to revocation list
+func (t *token) RevokeAccessToken(ctx context.Context, accessToken entity.AccessToken) error {
+	_, err := t.tokenClient.RevokeAccessToken(ctx, &grpc.AccessToken{
+		Jti:       accessToken.Jti,
+		UserId:    accessToken.UserId,
+		ExpiresAt: accessToken.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUser revokes every access and refresh token of user
+func (t *token) RevokeUser(ctx context.Context, userId string) error {
+	_, err := t.tokenClient.RevokeUserTokens(ctx, &grpc.AccessToken{
+		UserId: userId,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsRevoked checks access token against revocation list
+func (t *token) IsRevoked(ctx context.Context, accessToken entity.AccessToken) (bool, error) {
+	var checked entity.AccessToken
+	res, err := t.tokenClient.CheckAccessToken(ctx, &grpc.AccessToken{
+		Jti:       accessToken.Jti,
+		UserId:    accessToken.UserId,
+		IssuedAt:  accessToken.IssuedAt.Unix(),
+		ExpiresAt: accessToken.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	checked.ConvertFromProto(res)
+
+	return checked.Revoked, nil
+}
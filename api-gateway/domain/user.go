@@ -1,111 +1,342 @@
 package domain
 
 import (
-	"account-service/grpc"
+	accountv1 "account-service/grpc/account/v1"
 	"api-gateway/entity"
 	"context"
+	"io"
 
 	"github.com/sirupsen/logrus"
-	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type user struct {
 	logger     *logrus.Logger
-	userClient grpc.UserServiceClient
+	userClient accountv1.UserServiceClient
 }
 
 type UserInterface interface {
-	List(ctx context.Context) ([]entity.User, error)
+	List(ctx context.Context, query entity.UserQuery) (entity.UserPage, error)
+	Stream(ctx context.Context, fn func(entity.User) error) error
 	Get(ctx context.Context, filter entity.User) (entity.User, error)
+	BatchGet(ctx context.Context, ids []string) (entity.UserBatchGet, error)
 	Create(ctx context.Context, user entity.User) (entity.User, error)
-	Update(ctx context.Context, user entity.User) (entity.User, error)
+	Import(ctx context.Context, dryRun bool, next func() (entity.UserImportRow, bool, error)) (entity.UserImportReport, error)
+	Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error)
 	Delete(ctx context.Context, user entity.User) error
+	BatchDelete(ctx context.Context, ids []string) (entity.UserBatchDelete, error)
+	Restore(ctx context.Context, user entity.User) (entity.User, error)
+	VerifyCredentials(ctx context.Context, email, password string) (entity.User, error)
+	SetPassword(ctx context.Context, id, password string) error
+	ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error
+	History(ctx context.Context, id string, limit int, cursor string) (entity.UserHistory, error)
 }
 
 // initUser creates user domain
-func initUser(logger *logrus.Logger, userClient grpc.UserServiceClient) UserInterface {
+func initUser(logger *logrus.Logger, userClient accountv1.UserServiceClient) UserInterface {
 	return &user{
 		logger:     logger,
 		userClient: userClient,
 	}
 }
 
-// List returns list of users
-func (s *user) List(ctx context.Context) ([]entity.User, error) {
-	userList, err := s.userClient.GetUsers(ctx, &emptypb.Empty{})
+// List returns a page of users matching query
+func (s *user) List(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	req := &accountv1.ListUsersRequest{
+		PageSize:   int32(query.Limit),
+		Cursor:     query.Cursor,
+		Sort:       query.Sort,
+		Email:      query.Email,
+		NamePrefix: query.NamePrefix,
+		Deleted:    query.Deleted,
+	}
+
+	if !query.CreatedAfter.IsZero() {
+		req.CreatedAfter = timestamppb.New(query.CreatedAfter)
+	}
+
+	if !query.CreatedBefore.IsZero() {
+		req.CreatedBefore = timestamppb.New(query.CreatedBefore)
+	}
+
+	res, err := s.userClient.ListUsers(ctx, req)
+	if err != nil {
+		return entity.UserPage{}, err
+	}
+
+	page := entity.UserPage{
+		Users:      []entity.User{},
+		NextCursor: res.GetNextCursor(),
+	}
+	for i := range res.Users {
+		var user entity.User
+		user.ConvertFromProto(res.Users[i])
+		page.Users = append(page.Users, user)
+	}
+
+	return page, nil
+}
+
+// Stream calls fn with every user streamed by account-service, stopping at the first error of fn
+func (s *user) Stream(ctx context.Context, fn func(entity.User) error) error {
+	// cancelling ends the stream on account-service when fn stops early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s.userClient.StreamUsers(ctx, &accountv1.StreamUsersRequest{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var user entity.User
+		user.ConvertFromProto(res.GetUser())
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+}
+
+// Import streams rows returned by next to account-service until it reports no more rows
+func (s *user) Import(ctx context.Context, dryRun bool, next func() (entity.UserImportRow, bool, error)) (entity.UserImportReport, error) {
+	// cancelling aborts the import on account-service when next fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s.userClient.ImportUsers(ctx)
 	if err != nil {
-		return nil, err
+		return entity.UserImportReport{}, err
 	}
 
-	users := []entity.User{}
-	for i := range userList.Users {
-		users = append(users, entity.User{
-			Id:    userList.Users[i].Id,
-			Name:  userList.Users[i].Name,
-			Email: userList.Users[i].Email,
+	for {
+		row, ok, err := next()
+		if err != nil {
+			return entity.UserImportReport{}, err
+		} else if !ok {
+			break
+		}
+
+		err = stream.Send(&accountv1.ImportUsersRequest{
+			Row:    int32(row.Row),
+			Name:   row.Name,
+			Email:  row.Email,
+			Role:   row.Role,
+			DryRun: dryRun,
 		})
+		// account-service ended the stream, its error is returned by CloseAndRecv
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return entity.UserImportReport{}, err
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return entity.UserImportReport{}, err
 	}
 
-	return users, nil
+	report := entity.UserImportReport{
+		DryRun:     dryRun,
+		Created:    int(res.GetCreated()),
+		Duplicates: int(res.GetDuplicates()),
+		Invalid:    int(res.GetInvalid()),
+		Results:    []entity.UserImportResult{},
+	}
+	for _, result := range res.GetResults() {
+		report.Results = append(report.Results, entity.UserImportResult{
+			Row:    int(result.GetRow()),
+			Status: result.GetStatus(),
+			Id:     result.GetId(),
+			Email:  result.GetEmail(),
+			Reason: result.GetReason(),
+		})
+	}
+
+	return report, nil
 }
 
-// Get returns specific user by email
+// Get returns specific user by email, or by id when filter has no email
 func (s *user) Get(ctx context.Context, filter entity.User) (entity.User, error) {
+	req := &accountv1.GetUserRequest{Lookup: &accountv1.GetUserRequest_Id{Id: filter.Id}}
+	if filter.Email != "" {
+		req.Lookup = &accountv1.GetUserRequest_Email{Email: filter.Email}
+	}
+
 	var user entity.User
-	res, err := s.userClient.GetUser(ctx, &grpc.User{
-		Id:    filter.Id,
-		Name:  filter.Name,
-		Email: filter.Email,
-	})
+	res, err := s.userClient.GetUser(ctx, req)
 	if err != nil {
 		return user, err
 	}
-	user.ConvertFromProto(res)
+	user.ConvertFromProto(res.GetUser())
 
 	return user, nil
 }
 
+// BatchGet returns users with the given ids along with the ids that could not be got
+func (s *user) BatchGet(ctx context.Context, ids []string) (entity.UserBatchGet, error) {
+	var batch entity.UserBatchGet
+	res, err := s.userClient.BatchGetUsers(ctx, &accountv1.BatchGetUsersRequest{
+		Ids: ids,
+	})
+	if err != nil {
+		return batch, err
+	}
+	batch.ConvertFromProto(res)
+
+	return batch, nil
+}
+
 // Create creates new data
 func (s *user) Create(ctx context.Context, user entity.User) (entity.User, error) {
-	res, err := s.userClient.AddUser(ctx, &grpc.User{
+	res, err := s.userClient.CreateUser(ctx, &accountv1.CreateUserRequest{
 		Name:     user.Name,
 		Email:    user.Email,
 		Password: user.Password,
+		Role:     user.Role,
 	})
 	if err != nil {
 		return user, err
 	}
 
-	user.ConvertFromProto(res)
+	user.ConvertFromProto(res.GetUser())
 
 	return user, nil
 }
 
-// Update updates existing data
-func (s *user) Update(ctx context.Context, user entity.User) (entity.User, error) {
+// Update updates given fields of existing data, every non empty field when none is given
+func (s *user) Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error) {
 	var newUser entity.User
-	res, err := s.userClient.UpdateUser(ctx, &grpc.User{
-		Id:    user.Id,
-		Name:  user.Name,
-		Email: user.Email,
+	res, err := s.userClient.UpdateUser(ctx, &accountv1.UpdateUserRequest{
+		User: &accountv1.User{
+			Id:      user.Id,
+			Name:    user.Name,
+			Email:   user.Email,
+			Role:    user.Role,
+			Version: user.Version,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: fields},
 	})
 	if err != nil {
 		return newUser, err
 	}
 
-	newUser.ConvertFromProto(res)
+	newUser.ConvertFromProto(res.GetUser())
 
 	return newUser, nil
 }
 
 // Delete deletes existing data
 func (s *user) Delete(ctx context.Context, user entity.User) error {
-	_, err := s.userClient.DeleteUser(ctx, &grpc.User{
+	_, err := s.userClient.DeleteUser(ctx, &accountv1.DeleteUserRequest{
+		Id:      user.Id,
+		Version: user.Version,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BatchDelete deletes users with the given ids along with the ids that could not be deleted
+func (s *user) BatchDelete(ctx context.Context, ids []string) (entity.UserBatchDelete, error) {
+	var batch entity.UserBatchDelete
+	res, err := s.userClient.BatchDeleteUsers(ctx, &accountv1.BatchDeleteUsersRequest{
+		Ids: ids,
+	})
+	if err != nil {
+		return batch, err
+	}
+	batch.ConvertFromProto(res)
+
+	return batch, nil
+}
+
+// Restore brings back soft deleted user
+func (s *user) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	var restored entity.User
+	res, err := s.userClient.RestoreUser(ctx, &accountv1.RestoreUserRequest{
 		Id: user.Id,
 	})
+	if err != nil {
+		return restored, err
+	}
+	restored.ConvertFromProto(res.GetUser())
+
+	return restored, nil
+}
+
+// VerifyCredentials returns user matching email and password
+func (s *user) VerifyCredentials(ctx context.Context, email, password string) (entity.User, error) {
+	var user entity.User
+	res, err := s.userClient.VerifyCredentials(ctx, &accountv1.VerifyCredentialsRequest{
+		Email:    email,
+		Password: password,
+	})
+	if err != nil {
+		return user, err
+	}
+	user.ConvertFromProto(res.GetUser())
+
+	return user, nil
+}
+
+// SetPassword replaces password of user without checking the old one
+func (s *user) SetPassword(ctx context.Context, id, password string) error {
+	_, err := s.userClient.SetPassword(ctx, &accountv1.SetPasswordRequest{
+		Id:       id,
+		Password: password,
+	})
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// ChangePassword replaces password of user after checking the old one
+func (s *user) ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error {
+	_, err := s.userClient.ChangePassword(ctx, &accountv1.ChangePasswordRequest{
+		Id:          id,
+		OldPassword: oldPassword,
+		NewPassword: newPassword,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// History returns a page of changes made to user, newest first
+func (s *user) History(ctx context.Context, id string, limit int, cursor string) (entity.UserHistory, error) {
+	res, err := s.userClient.GetUserHistory(ctx, &accountv1.GetUserHistoryRequest{
+		UserId:   id,
+		PageSize: int32(limit),
+		Cursor:   cursor,
+	})
+	if err != nil {
+		return entity.UserHistory{}, err
+	}
+
+	history := entity.UserHistory{
+		Changes:    []entity.UserChange{},
+		NextCursor: res.GetNextCursor(),
+	}
+	for i := range res.Changes {
+		var change entity.UserChange
+		change.ConvertFromProto(res.Changes[i])
+		history.Changes = append(history.Changes, change)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,56 @@
+package entity
+
+import accountv1 "account-service/grpc/account/v1"
+
+const (
+	UserBatchNotFound  = "not_found"
+	UserBatchInvalidId = "invalid_id"
+	UserBatchForbidden = "forbidden"
+)
+
+type UserBatchRequest struct {
+	Ids []string `json:"ids" validate:"required,min=1,max=100"`
+}
+
+// UserBatchFailure tells why the user with Id was left out of a batch
+type UserBatchFailure struct {
+	Id     string `json:"id"`
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// UserBatchGet holds users found in the order of the requested ids
+type UserBatchGet struct {
+	Users    []User             `json:"users"`
+	Failures []UserBatchFailure `json:"failures"`
+}
+
+// UserBatchDelete holds ids of users deleted in the order of the requested ids
+type UserBatchDelete struct {
+	DeletedIds []string           `json:"deleted_ids"`
+	Failures   []UserBatchFailure `json:"failures"`
+}
+
+func convertBatchFailures(failures []*accountv1.BatchFailure) []UserBatchFailure {
+	res := []UserBatchFailure{}
+	for _, failure := range failures {
+		res = append(res, UserBatchFailure{Id: failure.GetId(), Code: failure.GetCode(), Reason: failure.GetReason()})
+	}
+
+	return res
+}
+
+func (b *UserBatchGet) ConvertFromProto(batch *accountv1.BatchGetUsersResponse) {
+	b.Users = []User{}
+	for _, res := range batch.GetUsers() {
+		user := User{}
+		user.ConvertFromProto(res)
+		b.Users = append(b.Users, user)
+	}
+	b.Failures = convertBatchFailures(batch.GetFailures())
+}
+
+func (b *UserBatchDelete) ConvertFromProto(batch *accountv1.BatchDeleteUsersResponse) {
+	b.DeletedIds = append([]string{}, batch.GetDeletedIds()...)
+	b.Failures = convertBatchFailures(batch.GetFailures())
+}
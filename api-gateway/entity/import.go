@@ -0,0 +1,40 @@
+package entity
+
+// statuses of an imported row
+const (
+	UserImportCreated   = "created"
+	UserImportValid     = "valid"
+	UserImportDuplicate = "duplicate"
+	UserImportInvalid   = "invalid"
+)
+
+type UserImportRequest struct {
+	DryRun bool `query:"dry_run"`
+}
+
+// UserImportRow is a user to import, Row is its line in the imported file
+type UserImportRow struct {
+	Row   int
+	Name  string
+	Email string
+	Role  string
+}
+
+// UserImportResult tells what happened to a row, status is created, valid, duplicate or invalid.
+// A valid row is one a dry run would have created.
+type UserImportResult struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	Id     string `json:"id,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// UserImportReport counts results by status, created counts valid rows on a dry run
+type UserImportReport struct {
+	DryRun     bool               `json:"dry_run"`
+	Created    int                `json:"created"`
+	Duplicates int                `json:"duplicates"`
+	Invalid    int                `json:"invalid"`
+	Results    []UserImportResult `json:"results"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+type Permission string
+
+const (
+	PermissionUserList           Permission = "user:list"
+	PermissionUserExport         Permission = "user:export"
+	PermissionUserImport         Permission = "user:import"
+	PermissionUserCreate         Permission = "user:create"
+	PermissionUserRead           Permission = "user:read"
+	PermissionUserReadHistory    Permission = "user:read_history"
+	PermissionUserUpdate         Permission = "user:update"
+	PermissionUserUpdatePassword Permission = "user:update_password"
+	PermissionUserDelete         Permission = "user:delete"
+	PermissionUserListDeleted    Permission = "user:list_deleted"
+	PermissionUserRestore        Permission = "user:restore"
+	PermissionUserRevokeSessions Permission = "user:revoke_sessions"
+)
@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"account-service/grpc"
+	"time"
+)
+
+type RefreshToken struct {
+	Token     string    `json:"token"`
+	UserId    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (t *RefreshToken) ConvertFromProto(token *grpc.RefreshToken) {
+	t.Token = token.GetToken()
+	t.UserId = token.GetUserId()
+	t.ExpiresAt = time.Unix(token.GetExpiresAt(), 0)
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type AccessToken struct {
+	Jti       string    `json:"jti"`
+	UserId    string    `json:"user_id"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Revoked   bool      `json:"revoked"`
+}
+
+func (t *AccessToken) ConvertFromProto(token *grpc.AccessToken) {
+	t.Jti = token.GetJti()
+	t.UserId = token.GetUserId()
+	t.IssuedAt = time.Unix(token.GetIssuedAt(), 0)
+	t.ExpiresAt = time.Unix(token.GetExpiresAt(), 0)
+	t.Revoked = token.GetRevoked()
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
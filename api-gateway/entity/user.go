@@ -1,36 +1,170 @@
 package entity
 
-import "account-service/grpc"
+import (
+	accountv1 "account-service/grpc/account/v1"
+	"encoding/json"
+	"time"
+)
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// fields of user that can be named in an update mask
+const (
+	UserFieldName  = "name"
+	UserFieldEmail = "email"
+	UserFieldRole  = "role"
+)
 
 type User struct {
-	Id       string `json:"id,omitempty" bson:"_id,omitempty"`
-	Name     string `json:"name" bson:"name,omitempty"`
-	Email    string `json:"email" bson:"email,omitempty"`
-	Password string `json:"-" bson:"password,omitempty"`
+	Id          string     `json:"id,omitempty" bson:"_id,omitempty"`
+	Name        string     `json:"name" bson:"name,omitempty"`
+	Email       string     `json:"email" bson:"email,omitempty"`
+	Password    string     `json:"-" bson:"password,omitempty"`
+	Role        string     `json:"role" bson:"role,omitempty"`
+	Version     int64      `json:"version" bson:"version,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at,omitempty"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" bson:"last_login_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	// PasswordResetRequired is set for imported users, they can not log in until an admin sets their password
+	PasswordResetRequired bool `json:"password_reset_required,omitempty" bson:"password_reset_required,omitempty"`
 }
 
-func (u *User) ConvertFromProto(user *grpc.User) {
+func (u *User) ConvertFromProto(user *accountv1.User) {
 	u.Id = user.GetId()
 	u.Name = user.GetName()
 	u.Email = user.GetEmail()
-	u.Password = user.GetPassword()
+	u.Role = user.GetRole()
+	u.Version = user.GetVersion()
+	u.CreatedAt = user.GetCreatedAt().AsTime()
+	u.UpdatedAt = user.GetUpdatedAt().AsTime()
+	u.PasswordResetRequired = user.GetPasswordResetRequired()
+	if user.GetLastLoginAt() != nil {
+		lastLoginAt := user.GetLastLoginAt().AsTime()
+		u.LastLoginAt = &lastLoginAt
+	}
+	if user.GetDeletedAt() != nil {
+		deletedAt := user.GetDeletedAt().AsTime()
+		u.DeletedAt = &deletedAt
+	}
+}
+
+type UserListRequest struct {
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+	Sort   string `query:"sort" validate:"omitempty,oneof=created -created name -name email -email"`
+	Filter string `query:"filter"`
+}
+
+// UserQuery selects a page of users, Cursor is the NextCursor of the previous page
+type UserQuery struct {
+	Limit         int
+	Cursor        string
+	Sort          string
+	Email         string
+	NamePrefix    string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Deleted       bool
+}
+
+type UserPage struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"next_cursor"`
+}
+
+type UserExportRequest struct {
+	Format string `query:"format" validate:"omitempty,oneof=csv ndjson"`
 }
 
 type UserCreateRequest struct {
 	Name  string `json:"name" validate:"required"`
 	Email string `json:"email" validate:"required"`
+	Role  string `json:"role" validate:"omitempty,oneof=admin user"`
 }
 
 type UserUpdateRequest struct {
 	Id    string `param:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required"`
+	Role  string `json:"role" validate:"omitempty,oneof=admin user"`
+}
+
+// UserPatchRequest is a JSON merge patch, members left out are kept and members set to null are cleared
+type UserPatchRequest struct {
+	Name  PatchString `json:"name" swaggertype:"string"`
+	Email PatchString `json:"email" swaggertype:"string"`
+	Role  PatchString `json:"role" swaggertype:"string"`
+}
+
+// PatchString tells apart a member left out of a merge patch from one set to null
+type PatchString struct {
+	Set   bool
+	Value string
+}
+
+func (p *PatchString) UnmarshalJSON(data []byte) error {
+	p.Set = true
+	if string(data) == "null" {
+		p.Value = ""
+		return nil
+	}
+
+	return json.Unmarshal(data, &p.Value)
 }
 
 type UserGetRequest struct {
 	Id string `param:"id" validate:"required"`
 }
 
+type UserHistoryRequest struct {
+	Id     string `param:"id" validate:"required"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+}
+
+// UserChange is a change made to user, ActorId is empty for changes made by the system
+type UserChange struct {
+	Id        string        `json:"id"`
+	UserId    string        `json:"user_id"`
+	ActorId   string        `json:"actor_id,omitempty"`
+	Action    string        `json:"action"`
+	Changes   []FieldChange `json:"changes"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+func (u *UserChange) ConvertFromProto(change *accountv1.UserChange) {
+	u.Id = change.GetId()
+	u.UserId = change.GetUserId()
+	u.ActorId = change.GetActorId()
+	u.Action = change.GetAction()
+	u.CreatedAt = change.GetCreatedAt().AsTime()
+	u.Changes = []FieldChange{}
+	for _, field := range change.GetChanges() {
+		u.Changes = append(u.Changes, FieldChange{Field: field.GetField(), OldValue: field.GetOldValue(), NewValue: field.GetNewValue()})
+	}
+}
+
+type UserHistory struct {
+	Changes    []UserChange `json:"changes"`
+	NextCursor string       `json:"next_cursor"`
+}
+
+type PasswordUpdateRequest struct {
+	Id          string `param:"id" validate:"required"`
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Name     string `json:"name" validate:"required"`
@@ -43,6 +177,7 @@ type LoginRequest struct {
 }
 
 type LoginResp struct {
-	Token   string `json:"token"`
-	Message string `json:"message"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	Message      string `json:"message"`
 }
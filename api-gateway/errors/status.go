@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FromStatus translates grpc status returned by account-service back into errors of this package
+func FromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.InvalidArgument:
+		violations := []error{}
+		for _, detail := range st.Details() {
+			badRequest, ok := detail.(*errdetails.BadRequest)
+			if !ok {
+				continue
+			}
+
+			for _, violation := range badRequest.GetFieldViolations() {
+				violations = append(violations, NewFieldError(violation.GetField(), violation.GetDescription()))
+			}
+		}
+
+		if len(violations) > 0 {
+			return errors.Join(violations...)
+		}
+
+		return fmt.Errorf("%w: %v", ErrBadRequest, st.Message())
+	case codes.Unauthenticated:
+		return fmt.Errorf("%w: %v", ErrUnauthorized, st.Message())
+	case codes.PermissionDenied:
+		return fmt.Errorf("%w: %v", ErrForbidden, st.Message())
+	case codes.NotFound:
+		return fmt.Errorf("%w: %v", ErrNotFound, st.Message())
+	case codes.AlreadyExists:
+		return fmt.Errorf("%w: %v", ErrDuplicatedKey, st.Message())
+	case codes.Aborted:
+		return fmt.Errorf("%w: %v", ErrPreconditionFailed, st.Message())
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	default:
+		return fmt.Errorf("%w: %v", ErrInternalServerError, st.Message())
+	}
+}
+
+// UnaryClientInterceptor applies FromStatus on every unary call to account-service
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return FromStatus(invoker(ctx, method, req, reply, cc, opts...))
+}
+
+// StreamClientInterceptor applies FromStatus on every message received from a stream of account-service
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, FromStatus(err)
+	}
+
+	return &statusClientStream{ClientStream: stream}, nil
+}
+
+type statusClientStream struct {
+	grpc.ClientStream
+}
+
+// RecvMsg keeps io.EOF as is, it is not a status
+func (s *statusClientStream) RecvMsg(m any) error {
+	return FromStatus(s.ClientStream.RecvMsg(m))
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"api-gateway/entity"
 	"api-gateway/errors"
+	"api-gateway/usecase"
 	"context"
 	"fmt"
 	"net/http"
@@ -15,8 +16,10 @@ import (
 type contextKey string
 
 const (
-	contextKeyUserId    contextKey = "user_id"
-	contextKeyUserEmail contextKey = "user_email"
+	contextKeyUserId      contextKey = "user_id"
+	contextKeyUserEmail   contextKey = "user_email"
+	contextKeyUserRole    contextKey = "user_role"
+	contextKeyAccessToken contextKey = "access_token"
 )
 
 // Register allow new user to register their account info
@@ -29,6 +32,7 @@ const (
 // @Param register body entity.RegisterRequest true "register request"
 // @Success 200 {object} entity.HttpResp{data=entity.User}
 // @Failure 400 {object} entity.HttpResp
+// @Failure 409 {object} entity.HttpResp
 // @Failure 500 {object} entity.HttpResp
 // @Router /v1/register [post]
 func (h *Handler) Register(c echo.Context) error {
@@ -41,15 +45,11 @@ func (h *Handler) Register(c echo.Context) error {
 		return h.httpError(c, errors.ErrBadRequest, err.Error())
 	}
 
-	hashedPassword, err := hashPassword(user.Password)
-	if err != nil {
-		return h.httpError(c, err)
-	}
-
 	createReq := entity.User{
 		Email:    user.Email,
 		Name:     user.Name,
-		Password: hashedPassword,
+		Password: user.Password,
+		Role:     entity.RoleUser,
 	}
 	newUser, err := h.user.Create(c.Request().Context(), createReq)
 	if err != nil {
@@ -81,14 +81,66 @@ func (h *Handler) Login(c echo.Context) error {
 		return h.httpError(c, errors.ErrBadRequest, err.Error())
 	}
 
-	user, err := h.user.Get(c.Request().Context(), entity.User{Email: loginReq.Email})
+	user, err := h.user.VerifyCredentials(c.Request().Context(), loginReq.Email, loginReq.Password)
 	if err != nil {
-		h.logger.Error(err)
+		h.logger.WithContext(c.Request().Context()).Error(err)
 		return h.httpError(c, errors.ErrUnauthorized, "email/password does not match")
 	}
 
-	if err := checkPasswordHash(user.Password, loginReq.Password); err != nil {
-		return h.httpError(c, errors.ErrUnauthorized, "email/password does not match")
+	token, err := h.createToken(user)
+	if err != nil {
+		return h.httpError(c, err)
+	}
+
+	refreshToken, err := h.token.Issue(c.Request().Context(), user.Id)
+	if err != nil {
+		return h.httpError(c, err)
+	}
+
+	resp := entity.LoginResp{
+		Token:        token,
+		RefreshToken: refreshToken.Token,
+		Message:      "successful login",
+	}
+
+	return h.httpSuccess(c, http.StatusOK, resp)
+}
+
+// RefreshToken exchange refresh token with a new token pair
+//
+// @Summary Refresh access token
+// @Description Exchange refresh token with new access and refresh token, the old refresh token can not be used again
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body entity.RefreshTokenRequest true "refresh token request"
+// @Success 200 {object} entity.HttpResp{data=entity.LoginResp}
+// @Failure 400 {object} entity.HttpResp
+// @Failure 401 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/token/refresh [post]
+func (h *Handler) RefreshToken(c echo.Context) error {
+	req := entity.RefreshTokenRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	// everything that may fail runs before rotation, which uses the refresh token up,
+	// so that a retry after a failure does not look like reuse and revoke the session
+	current, err := h.token.Check(c.Request().Context(), req.RefreshToken)
+	if err != nil {
+		h.logger.WithContext(c.Request().Context()).Error(err)
+		return h.httpError(c, errors.ErrUnauthorized, "invalid refresh token")
+	}
+
+	user, err := h.user.Get(c.Request().Context(), entity.User{Id: current.UserId})
+	if err != nil {
+		h.logger.WithContext(c.Request().Context()).Error(err)
+		return h.httpError(c, errors.ErrUnauthorized, "invalid refresh token")
 	}
 
 	token, err := h.createToken(user)
@@ -96,14 +148,60 @@ func (h *Handler) Login(c echo.Context) error {
 		return h.httpError(c, err)
 	}
 
+	refreshToken, err := h.token.Rotate(c.Request().Context(), req.RefreshToken)
+	if err != nil {
+		h.logger.WithContext(c.Request().Context()).Error(err)
+		return h.httpError(c, errors.ErrUnauthorized, "invalid refresh token")
+	}
+
 	resp := entity.LoginResp{
-		Token:   token,
-		Message: "successful login",
+		Token:        token,
+		RefreshToken: refreshToken.Token,
+		Message:      "successful refresh",
 	}
 
 	return h.httpSuccess(c, http.StatusOK, resp)
 }
 
+// Logout revokes current access token along with its refresh token
+//
+// @Summary Logout current session
+// @Description Revoke access token used in this request, and refresh token if given
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param logout body entity.LogoutRequest false "logout request"
+// @Success 200 {object} entity.HttpResp
+// @Failure 400 {object} entity.HttpResp
+// @Failure 401 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/logout [post]
+func (h *Handler) Logout(c echo.Context) error {
+	req := entity.LogoutRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	accessToken, ok := c.Request().Context().Value(contextKeyAccessToken).(entity.AccessToken)
+	if !ok {
+		return h.httpError(c, errors.ErrUnauthorized, "missing token")
+	}
+
+	if err := h.token.RevokeAccessToken(c.Request().Context(), accessToken); err != nil {
+		return h.httpError(c, err)
+	}
+
+	if req.RefreshToken != "" {
+		if err := h.token.RevokeRefreshToken(c.Request().Context(), req.RefreshToken); err != nil {
+			h.logger.WithContext(c.Request().Context()).Error(err)
+			return h.httpError(c, errors.ErrUnauthorized, "invalid refresh token")
+		}
+	}
+
+	return h.httpSuccess(c, http.StatusOK, nil)
+}
+
 func (h *Handler) Authorize(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tokenString := c.Request().Header.Get("Authorization")
@@ -120,9 +218,7 @@ func (h *Handler) checkToken(c echo.Context, tokenString string) error {
 		return fmt.Errorf("missing token")
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(h.config.Auth.SecretKey), nil
-	})
+	token, err := h.key.Parse(tokenString)
 	if err != nil {
 		return fmt.Errorf("failed to parse token")
 	}
@@ -144,9 +240,40 @@ func (h *Handler) checkToken(c echo.Context, tokenString string) error {
 		return fmt.Errorf("token expired")
 	}
 
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return fmt.Errorf("invalid token")
+	}
+
+	issuedAt, ok := claims["iat"].(float64)
+	if !ok {
+		return fmt.Errorf("invalid token")
+	}
+
+	userId, _ := claims["user_id"].(string)
+	accessToken := entity.AccessToken{
+		Jti:       jti,
+		UserId:    userId,
+		IssuedAt:  time.Unix(int64(issuedAt), 0),
+		ExpiresAt: expirationTime,
+	}
+
+	revoked, err := h.token.IsRevoked(c.Request().Context(), accessToken)
+	if err != nil {
+		h.logger.WithContext(c.Request().Context()).Error(err)
+		return fmt.Errorf("failed to check token")
+	}
+
+	if revoked {
+		return fmt.Errorf("token revoked")
+	}
+
 	ctx := c.Request().Context()
 	ctx = context.WithValue(ctx, contextKeyUserId, claims["user_id"])
 	ctx = context.WithValue(ctx, contextKeyUserEmail, claims["user_email"])
+	ctx = context.WithValue(ctx, contextKeyUserRole, claims["role"])
+	ctx = context.WithValue(ctx, contextKeyAccessToken, accessToken)
+	ctx = usecase.WithActor(ctx, userId)
 
 	c.SetRequest(c.Request().WithContext(ctx))
 
@@ -154,16 +281,35 @@ func (h *Handler) checkToken(c echo.Context, tokenString string) error {
 }
 
 func (h *Handler) createToken(user entity.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	jti, err := generateJti()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	tokenString, err := h.key.Sign(jwt.MapClaims{
+		"jti":        jti,
 		"user_id":    user.Id,
 		"user_email": user.Email,
-		"exp":        time.Now().Add(time.Hour * 1).Unix(),
+		"role":       user.Role,
+		"iat":        now.Unix(),
+		"exp":        now.Add(h.config.Auth.AccessTokenTTL).Unix(),
 	})
-
-	tokenString, err := token.SignedString([]byte(h.config.Auth.SecretKey))
 	if err != nil {
 		return "", err
 	}
 
 	return tokenString, nil
 }
+
+// JWKS publishes public keys used to sign access tokens
+//
+// @Summary Get JSON web key set
+// @Description Returns public keys to verify access tokens, selected by the kid header of the token
+// @Tags auth
+// @Produce json
+// @Success 200 {object} entity.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) JWKS(c echo.Context) error {
+	return h.ResponseLogging(c, http.StatusOK, h.key.JWKS())
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"api-gateway/entity"
+	"api-gateway/errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BatchGetUsers returns several users at once
+//
+// @Summary Batch get users
+// @Description Get up to 100 users by id in one request, users are returned in the order of the ids. Ids that are malformed, not found or not readable by the logged in user are listed in failures instead of failing the request.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param ids body entity.UserBatchRequest true "ids of the users"
+// @Success 200 {object} entity.HttpResp{data=entity.UserBatchGet}
+// @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users:batchGet [post]
+func (h *Handler) BatchGetUsers(c echo.Context) error {
+	req := entity.UserBatchRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, err)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	ids, forbidden := permittedIds(c, entity.PermissionUserRead, req.Ids)
+
+	batch := entity.UserBatchGet{Users: []entity.User{}, Failures: []entity.UserBatchFailure{}}
+	if len(ids) > 0 {
+		var err error
+		batch, err = h.user.BatchGet(c.Request().Context(), ids)
+		if err != nil {
+			return h.httpError(c, err)
+		}
+	}
+	batch.Failures = append(batch.Failures, forbidden...)
+
+	return h.httpSuccess(c, http.StatusOK, batch)
+}
+
+// BatchDeleteUsers deletes several users at once
+//
+// @Summary Batch delete users
+// @Description Delete up to 100 users by id in one request regardless of their version, so If-Match can only be *. Ids that are malformed, not found or not deletable by the logged in user are listed in failures instead of failing the request.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param ids body entity.UserBatchRequest true "ids of the users"
+// @Param If-Match header string false "*, required when server enforces conditional requests"
+// @Success 200 {object} entity.HttpResp{data=entity.UserBatchDelete}
+// @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 412 {object} entity.HttpResp
+// @Failure 428 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users:batchDelete [post]
+func (h *Handler) BatchDeleteUsers(c echo.Context) error {
+	req := entity.UserBatchRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, err)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	// users of a batch have versions of their own, a single version can not apply to all of them
+	version, err := h.ifMatchVersion(c)
+	if err != nil {
+		return h.httpError(c, err)
+	} else if version != 0 {
+		return h.httpError(c, errors.ErrPreconditionFailed, "If-Match of a batch can only be *")
+	}
+
+	ids, forbidden := permittedIds(c, entity.PermissionUserDelete, req.Ids)
+
+	batch := entity.UserBatchDelete{DeletedIds: []string{}, Failures: []entity.UserBatchFailure{}}
+	if len(ids) > 0 {
+		batch, err = h.user.BatchDelete(c.Request().Context(), ids)
+		if err != nil {
+			return h.httpError(c, err)
+		}
+	}
+	batch.Failures = append(batch.Failures, forbidden...)
+
+	return h.httpSuccess(c, http.StatusOK, batch)
+}
+
+// permittedIds splits ids into those the logged in user has the permission on and failures for the others
+func permittedIds(c echo.Context, perm entity.Permission, ids []string) ([]string, []entity.UserBatchFailure) {
+	permittedIds := []string{}
+	forbidden := []entity.UserBatchFailure{}
+	for _, id := range ids {
+		if !permitted(c, perm, id) {
+			forbidden = append(forbidden, entity.UserBatchFailure{Id: id, Code: entity.UserBatchForbidden, Reason: "insufficient permission"})
+			continue
+		}
+
+		permittedIds = append(permittedIds, id)
+	}
+
+	return permittedIds, forbidden
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"api-gateway/entity"
+	"testing"
+)
+
+func TestPermittedIds(t *testing.T) {
+	tests := []struct {
+		name          string
+		role          string
+		ids           []string
+		wantPermitted []string
+		wantForbidden []string
+	}{
+		{"admin gets every id", entity.RoleAdmin, []string{"user", "other"}, []string{"user", "other"}, []string{}},
+		{"user gets only own id", entity.RoleUser, []string{"other", "user", "third"}, []string{"user"}, []string{"other", "third"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(tt.role, "user", "")
+
+			permitted, forbidden := permittedIds(c, entity.PermissionUserRead, tt.ids)
+
+			if len(permitted) != len(tt.wantPermitted) {
+				t.Fatalf("got permitted %v, want %v", permitted, tt.wantPermitted)
+			}
+			for i := range permitted {
+				if permitted[i] != tt.wantPermitted[i] {
+					t.Fatalf("got permitted %v, want %v", permitted, tt.wantPermitted)
+				}
+			}
+
+			if len(forbidden) != len(tt.wantForbidden) {
+				t.Fatalf("got forbidden %+v, want ids %v", forbidden, tt.wantForbidden)
+			}
+			for i := range forbidden {
+				if forbidden[i].Id != tt.wantForbidden[i] || forbidden[i].Code != entity.UserBatchForbidden {
+					t.Fatalf("got forbidden %+v, want ids %v", forbidden, tt.wantForbidden)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"api-gateway/errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// setETag exposes version of a resource so clients can send it back in If-Match
+func setETag(c echo.Context, version int64) {
+	c.Response().Header().Set(headerETag, fmt.Sprintf("%q", strconv.FormatInt(version, 10)))
+}
+
+// ifMatchVersion returns version required by If-Match header, zero means any version.
+// The header is mandatory when SERVER_REQUIRE_IF_MATCH is set.
+func (h *Handler) ifMatchVersion(c echo.Context) (int64, error) {
+	ifMatch := strings.TrimSpace(c.Request().Header.Get(headerIfMatch))
+	switch ifMatch {
+	case "":
+		if h.config.Server.RequireIfMatch {
+			return 0, errors.ErrPreconditionNeeded
+		}
+		return 0, nil
+	case "*":
+		return 0, nil
+	}
+
+	// weak validators are compared the same way, versions are never reused
+	etag := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+	version, err := strconv.ParseInt(etag, 10, 64)
+	if err != nil || version < 1 {
+		return 0, errors.ErrPreconditionFailed
+	}
+
+	return version, nil
+}
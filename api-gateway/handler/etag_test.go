@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"api-gateway/entity"
+	"api-gateway/errors"
+	"testing"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		require bool
+		want    int64
+		wantErr error
+	}{
+		{"missing", "", false, 0, nil},
+		{"missing when required", "", true, 0, errors.ErrPreconditionNeeded},
+		{"any", "*", true, 0, nil},
+		{"strong", `"3"`, true, 3, nil},
+		{"weak", `W/"3"`, false, 3, nil},
+		{"unquoted", "3", false, 3, nil},
+		{"surrounding spaces", ` "12" `, false, 12, nil},
+		{"zero", `"0"`, false, 0, errors.ErrPreconditionFailed},
+		{"negative", `"-1"`, false, 0, errors.ErrPreconditionFailed},
+		{"not a version", `"abc"`, false, 0, errors.ErrPreconditionFailed},
+		{"several etags", `"1", "2"`, false, 0, errors.ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := initTestHandler()
+			h.config.Server.RequireIfMatch = tt.require
+
+			c, _ := newTestContext(entity.RoleUser, "user", "user")
+			if tt.ifMatch != "" {
+				c.Request().Header.Set(headerIfMatch, tt.ifMatch)
+			}
+
+			version, err := h.ifMatchVersion(c)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if version != tt.want {
+				t.Fatalf("got version %v, want %v", version, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"api-gateway/entity"
+	"api-gateway/errors"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// exportFlushEvery is how many users are written before the response is flushed to the client
+const exportFlushEvery = 100
+
+// ExportUsers streams every user as a file download
+//
+// @Summary Export users
+// @Description Download every user that is not soft deleted as CSV or newline delimited JSON. The response is streamed in chunks, a download cut short means the export failed midway.
+// @Tags users
+// @Security BearerAuth
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "file format" Enums(csv, ndjson) default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users/export [get]
+func (h *Handler) ExportUsers(c echo.Context) error {
+	req := entity.UserExportRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+
+	var writer userExportWriter
+	switch req.Format {
+	case "ndjson":
+		writer = &ndjsonExportWriter{encoder: json.NewEncoder(c.Response())}
+	default:
+		writer = &csvExportWriter{writer: csv.NewWriter(c.Response())}
+	}
+
+	// headers are only sent with the first user, so that failing before it still gets an error response
+	started := false
+	start := func() error {
+		started = true
+
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentType, writer.contentType())
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "users-"+time.Now().Format("20060102")+"."+req.Format))
+		res.WriteHeader(http.StatusOK)
+
+		return writer.start()
+	}
+
+	written := 0
+	err := h.user.Stream(c.Request().Context(), func(user entity.User) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.write(user); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushEvery == 0 {
+			return writer.flush(c.Response())
+		}
+
+		return nil
+	})
+	if err != nil && !started {
+		return h.httpError(c, err)
+	} else if err != nil {
+		// status was already sent, the client only sees a truncated download
+		h.logger.WithContext(c.Request().Context()).Errorf("export of users failed after %v users: %v", written, err)
+		return nil
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+
+	return writer.flush(c.Response())
+}
+
+type userExportWriter interface {
+	contentType() string
+	start() error
+	write(user entity.User) error
+	flush(res *echo.Response) error
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvExportWriter) contentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (w *csvExportWriter) start() error {
+	return w.writer.Write([]string{"id", "name", "email", "role", "version", "created_at", "updated_at", "last_login_at"})
+}
+
+func (w *csvExportWriter) write(user entity.User) error {
+	lastLoginAt := ""
+	if user.LastLoginAt != nil {
+		lastLoginAt = user.LastLoginAt.Format(time.RFC3339)
+	}
+
+	return w.writer.Write([]string{
+		user.Id,
+		csvText(user.Name),
+		csvText(user.Email),
+		csvText(user.Role),
+		strconv.FormatInt(user.Version, 10),
+		user.CreatedAt.Format(time.RFC3339),
+		user.UpdatedAt.Format(time.RFC3339),
+		lastLoginAt,
+	})
+}
+
+// csvText keeps spreadsheets from evaluating text given by users as a formula, the quote
+// makes them show the cell as text and is what OWASP recommends against CSV injection
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+func (w *csvExportWriter) flush(res *echo.Response) error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return err
+	}
+	res.Flush()
+
+	return nil
+}
+
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonExportWriter) contentType() string {
+	return "application/x-ndjson"
+}
+
+func (w *ndjsonExportWriter) start() error {
+	return nil
+}
+
+// write puts user on a line of its own, json.Encoder ends every value with a newline
+func (w *ndjsonExportWriter) write(user entity.User) error {
+	return w.encoder.Encode(user)
+}
+
+func (w *ndjsonExportWriter) flush(res *echo.Response) error {
+	res.Flush()
+	return nil
+}
@@ -1,13 +1,13 @@
 package handler
 
 import (
+	"account-service/logging"
 	"api-gateway/config"
 	"api-gateway/entity"
 	"api-gateway/errors"
 	"api-gateway/usecase"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"runtime"
@@ -24,6 +24,8 @@ type Handler struct {
 	validator *validator.Validate
 	logger    *logrus.Logger
 	user      usecase.UserInterface
+	token     usecase.TokenInterface
+	key       usecase.KeyInterface
 }
 
 // Init create new Handler object
@@ -33,6 +35,8 @@ func Init(config *config.Value, uc *usecase.Usecases, validator *validator.Valid
 		validator: validator,
 		logger:    logger,
 		user:      uc.User,
+		token:     uc.Token,
+		key:       uc.Key,
 	}
 }
 
@@ -44,13 +48,19 @@ func (h *Handler) Ping(c echo.Context) error {
 func (h *Handler) httpError(c echo.Context, err error, additionalMessage ...string) error {
 	p, _ := os.Getwd()
 
+	// the caller of httpError is where the error was handled, caller of the entry is always httpError
 	_, filename, line, _ := runtime.Caller(1)
-	log.Printf("\033[31m[error]\033[0m \033[35m%s:%d\033[0m -> %v", strings.TrimPrefix(filename, p), line, err)
+	h.logger.WithContext(c.Request().Context()).
+		WithField("handled_at", fmt.Sprintf("%s:%d", strings.TrimPrefix(filename, p), line)).
+		Error(err)
+
+	requestId := logging.RequestIdFromContext(c.Request().Context())
 
 	resp := entity.HttpResp{
-		Status:  errors.GetStatusCode(err),
-		Message: http.StatusText(errors.GetStatusCode(err)),
-		Error:   fmt.Sprintf("%s. %s", err.Error(), additionalMessage),
+		Status:    errors.GetStatusCode(err),
+		Message:   http.StatusText(errors.GetStatusCode(err)),
+		Error:     fmt.Sprintf("%s. %s", err.Error(), additionalMessage),
+		RequestId: requestId,
 	}
 
 	return h.ResponseLogging(c, errors.GetStatusCode(err), resp)
@@ -69,7 +79,7 @@ func (h *Handler) httpSuccess(c echo.Context, statusCode int, data any) error {
 
 func (h *Handler) ResponseLogging(c echo.Context, code int, resp any) error {
 	res, _ := json.Marshal(resp)
-	trail := h.logger.WithFields(
+	trail := h.logger.WithContext(c.Request().Context()).WithFields(
 		logrus.Fields{
 			"at":   time.Now().Format(time.RFC3339),
 			"resp": string(res),
@@ -83,7 +93,7 @@ func (h *Handler) ResponseLogging(c echo.Context, code int, resp any) error {
 
 func (h *Handler) MiddlewareLogging(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		trail := h.logger.WithFields(
+		trail := h.logger.WithContext(c.Request().Context()).WithFields(
 			logrus.Fields{
 				"at":     time.Now().Format(time.RFC3339),
 				"method": c.Request().Method,
@@ -97,3 +107,15 @@ func (h *Handler) MiddlewareLogging(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+// MiddlewareRequestId keeps the X-Request-ID sent by the client when it is valid and makes a new one otherwise,
+// the id is sent back and stored in the request context so that logs and calls to account-service carry it
+func (h *Handler) MiddlewareRequestId(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestId := logging.RequestId(c.Request().Header.Get(echo.HeaderXRequestID))
+		c.Response().Header().Set(echo.HeaderXRequestID, requestId)
+		c.SetRequest(c.Request().WithContext(logging.WithRequestId(c.Request().Context(), requestId)))
+
+		return next(c)
+	}
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"api-gateway/entity"
+	"api-gateway/errors"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxImportLine bounds a single ndjson line
+const maxImportLine = 1 << 20
+
+// ImportUsers creates users from an uploaded file
+//
+// @Summary Import users
+// @Description Create users from CSV with a header row or from newline delimited JSON, both with name, email and optionally role; other columns or members are ignored so an export can be imported back. Every row is reported as created, duplicate or invalid with the reason. With dry_run nothing is created and rows that would be created are reported valid. Imported users have password_reset_required set and can not log in until their password is set through PUT /v1/users/{id}/password.
+// @Tags users
+// @Security BearerAuth
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param dry_run query bool false "only validate the rows"
+// @Success 200 {object} entity.HttpResp{data=entity.UserImportReport}
+// @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 415 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users/import [post]
+func (h *Handler) ImportUsers(c echo.Context) error {
+	req := entity.UserImportRequest{}
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, &req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	// rows the gateway can not even parse are reported here, account-service reports the others
+	rejected := []entity.UserImportResult{}
+	reject := func(row int, reason string) {
+		rejected = append(rejected, entity.UserImportResult{Row: row, Status: entity.UserImportInvalid, Reason: reason})
+	}
+
+	contentType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+
+	var next func() (entity.UserImportRow, bool, error)
+	switch contentType {
+	case "text/csv":
+		var err error
+		next, err = csvImportRows(c.Request().Body, reject)
+		if err != nil {
+			return h.httpError(c, err)
+		}
+	case "application/x-ndjson":
+		next = ndjsonImportRows(c.Request().Body, reject)
+	default:
+		return h.httpError(c, errors.ErrUnsupportedMedia, "use text/csv or application/x-ndjson")
+	}
+
+	report, err := h.user.Import(c.Request().Context(), req.DryRun, next)
+	if err != nil {
+		return h.httpError(c, err)
+	}
+
+	report.Invalid += len(rejected)
+	report.Results = append(report.Results, rejected...)
+	sort.SliceStable(report.Results, func(i, j int) bool {
+		return report.Results[i].Row < report.Results[j].Row
+	})
+
+	return h.httpSuccess(c, http.StatusOK, report)
+}
+
+// csvImportRows reads rows of csv with a header row naming its columns
+func csvImportRows(body io.Reader, reject func(row int, reason string)) (func() (entity.UserImportRow, bool, error), error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.NewFieldError("Body", "csv must start with a header row")
+	} else if err != nil {
+		return nil, errors.NewFieldError("Body", err.Error())
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	for _, column := range []string{"name", "email"} {
+		if _, ok := columns[column]; !ok {
+			return nil, errors.NewFieldError("Body", fmt.Sprintf("csv header has no %v column", column))
+		}
+	}
+
+	value := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	return func() (entity.UserImportRow, bool, error) {
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return entity.UserImportRow{}, false, nil
+			}
+
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				reject(parseErr.StartLine, parseErr.Err.Error())
+				continue
+			} else if err != nil {
+				return entity.UserImportRow{}, false, fmt.Errorf("%w: %v", errors.ErrBadRequest, err)
+			}
+
+			line, _ := reader.FieldPos(0)
+			return entity.UserImportRow{
+				Row:   line,
+				Name:  value(record, "name"),
+				Email: value(record, "email"),
+				Role:  value(record, "role"),
+			}, true, nil
+		}
+	}, nil
+}
+
+// ndjsonImportRows reads a json object per line, blank lines are skipped
+func ndjsonImportRows(body io.Reader, reject func(row int, reason string)) func() (entity.UserImportRow, bool, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+	line := 0
+
+	return func() (entity.UserImportRow, bool, error) {
+		for scanner.Scan() {
+			line++
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+
+			row := struct {
+				Name  string `json:"name"`
+				Email string `json:"email"`
+				Role  string `json:"role"`
+			}{}
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				reject(line, "malformed json: "+err.Error())
+				continue
+			}
+
+			return entity.UserImportRow{
+				Row:   line,
+				Name:  strings.TrimSpace(row.Name),
+				Email: strings.TrimSpace(row.Email),
+				Role:  strings.TrimSpace(row.Role),
+			}, true, nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			return entity.UserImportRow{}, false, fmt.Errorf("%w: line %v: %v", errors.ErrBadRequest, line+1, err)
+		}
+
+		return entity.UserImportRow{}, false, nil
+	}
+}
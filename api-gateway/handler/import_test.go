@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"api-gateway/entity"
+	"api-gateway/errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readImportRows drains next, returning its rows along with the rows it rejected
+func readImportRows(t *testing.T, newNext func(reject func(row int, reason string)) (func() (entity.UserImportRow, bool, error), error)) ([]entity.UserImportRow, []int, error) {
+	t.Helper()
+
+	rejected := []int{}
+	next, err := newNext(func(row int, reason string) {
+		if reason == "" {
+			t.Fatalf("row %v rejected without a reason", row)
+		}
+		rejected = append(rejected, row)
+	})
+	if err != nil {
+		return nil, rejected, err
+	}
+
+	rows := []entity.UserImportRow{}
+	for {
+		row, ok, err := next()
+		if err != nil {
+			return rows, rejected, err
+		} else if !ok {
+			return rows, rejected, nil
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestCsvImportRows(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		want         []entity.UserImportRow
+		wantRejected []int
+		wantErr      bool
+	}{
+		{
+			name: "rows after header",
+			body: "name,email,role\njane,jane@example.com,admin\njohn,john@example.com,\n",
+			want: []entity.UserImportRow{
+				{Row: 2, Name: "jane", Email: "jane@example.com", Role: "admin"},
+				{Row: 3, Name: "john", Email: "john@example.com"},
+			},
+		},
+		{
+			name: "columns in any order and case, others ignored",
+			body: "Id, EMAIL ,created_at,Name\n1, jane@example.com ,2024-01-01, jane \n",
+			want: []entity.UserImportRow{
+				{Row: 2, Name: "jane", Email: "jane@example.com"},
+			},
+		},
+		{
+			name: "quoted cell spanning lines",
+			body: "name,email\n\"Doe,\nJane\",jane@example.com\njohn,john@example.com\n",
+			want: []entity.UserImportRow{
+				{Row: 2, Name: "Doe,\nJane", Email: "jane@example.com"},
+				{Row: 4, Name: "john", Email: "john@example.com"},
+			},
+		},
+		{
+			name: "malformed rows are rejected, later rows are read",
+			body: "name,email\njane,jane@\"example.com\njohn\njim,jim@example.com\n",
+			want: []entity.UserImportRow{
+				{Row: 4, Name: "jim", Email: "jim@example.com"},
+			},
+			wantRejected: []int{2, 3},
+		},
+		{
+			name:    "empty body",
+			body:    "",
+			wantErr: true,
+		},
+		{
+			name:    "header without email",
+			body:    "name,mail\njane,jane@example.com\n",
+			wantErr: true,
+		},
+		{
+			name: "header only",
+			body: "name,email\n",
+			want: []entity.UserImportRow{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rejected, err := readImportRows(t, func(reject func(row int, reason string)) (func() (entity.UserImportRow, bool, error), error) {
+				return csvImportRows(strings.NewReader(tt.body), reject)
+			})
+			if tt.wantErr {
+				if !errors.Is(err, errors.ErrBadRequest) {
+					t.Fatalf("got error %v, want a bad request", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Fatalf("got rows %+v, want %+v", rows, tt.want)
+			}
+			if len(tt.wantRejected) == 0 {
+				tt.wantRejected = []int{}
+			}
+			if !reflect.DeepEqual(rejected, tt.wantRejected) {
+				t.Fatalf("got rows %v rejected, want %v", rejected, tt.wantRejected)
+			}
+		})
+	}
+}
+
+func TestNdjsonImportRows(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		want         []entity.UserImportRow
+		wantRejected []int
+		wantErr      bool
+	}{
+		{
+			name: "object per line",
+			body: `{"name":"jane","email":"jane@example.com","role":"admin"}` + "\n" + `{"name":" john ","email":"john@example.com"}`,
+			want: []entity.UserImportRow{
+				{Row: 1, Name: "jane", Email: "jane@example.com", Role: "admin"},
+				{Row: 2, Name: "john", Email: "john@example.com"},
+			},
+		},
+		{
+			name: "blank lines are skipped but counted",
+			body: "\n" + `{"name":"jane","email":"jane@example.com"}` + "\n  \n" + `{"name":"john","email":"john@example.com"}` + "\n",
+			want: []entity.UserImportRow{
+				{Row: 2, Name: "jane", Email: "jane@example.com"},
+				{Row: 4, Name: "john", Email: "john@example.com"},
+			},
+		},
+		{
+			name: "other members are ignored",
+			body: `{"id":"1","name":"jane","email":"jane@example.com","created_at":"2024-01-01T00:00:00Z"}`,
+			want: []entity.UserImportRow{
+				{Row: 1, Name: "jane", Email: "jane@example.com"},
+			},
+		},
+		{
+			name: "malformed lines are rejected, later lines are read",
+			body: `{"name":"jane"` + "\n" + `["jane"]` + "\n" + `{"name":"john","email":"john@example.com"}`,
+			want: []entity.UserImportRow{
+				{Row: 3, Name: "john", Email: "john@example.com"},
+			},
+			wantRejected: []int{1, 2},
+		},
+		{
+			name:    "line too long",
+			body:    `{"name":"` + strings.Repeat("a", maxImportLine) + `"}`,
+			want:    []entity.UserImportRow{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rejected, err := readImportRows(t, func(reject func(row int, reason string)) (func() (entity.UserImportRow, bool, error), error) {
+				return ndjsonImportRows(strings.NewReader(tt.body), reject), nil
+			})
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, errors.ErrBadRequest) {
+				t.Fatalf("got error %v, want a bad request", err)
+			}
+
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Fatalf("got rows %+v, want %+v", rows, tt.want)
+			}
+			if len(tt.wantRejected) == 0 {
+				tt.wantRejected = []int{}
+			}
+			if !reflect.DeepEqual(rejected, tt.wantRejected) {
+				t.Fatalf("got rows %v rejected, want %v", rejected, tt.wantRejected)
+			}
+		})
+	}
+}
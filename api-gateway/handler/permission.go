@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"api-gateway/entity"
+	"api-gateway/errors"
+
+	"github.com/labstack/echo/v4"
+)
+
+type scope int
+
+const (
+	// scopeOwn allows the action only on the record of the logged in user
+	scopeOwn scope = iota + 1
+	// scopeAny allows the action on every record
+	scopeAny
+)
+
+// rolePermissions lists what each role is allowed to do
+var rolePermissions = map[string]map[entity.Permission]scope{
+	entity.RoleAdmin: {
+		entity.PermissionUserList:           scopeAny,
+		entity.PermissionUserExport:         scopeAny,
+		entity.PermissionUserImport:         scopeAny,
+		entity.PermissionUserCreate:         scopeAny,
+		entity.PermissionUserRead:           scopeAny,
+		entity.PermissionUserReadHistory:    scopeAny,
+		entity.PermissionUserUpdate:         scopeAny,
+		entity.PermissionUserUpdatePassword: scopeAny,
+		entity.PermissionUserDelete:         scopeAny,
+		entity.PermissionUserListDeleted:    scopeAny,
+		entity.PermissionUserRestore:        scopeAny,
+		entity.PermissionUserRevokeSessions: scopeAny,
+	},
+	entity.RoleUser: {
+		entity.PermissionUserRead:           scopeOwn,
+		entity.PermissionUserReadHistory:    scopeOwn,
+		entity.PermissionUserUpdate:         scopeOwn,
+		entity.PermissionUserUpdatePassword: scopeOwn,
+	},
+}
+
+// Permit only lets the request through when role of logged in user grants the permission,
+// must be used after Authorize. Own scope is checked against the id path param.
+func (h *Handler) Permit(perm entity.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if permitted(c, perm, c.Param("id")) {
+				return next(c)
+			}
+
+			return h.httpError(c, errors.ErrForbidden, "insufficient permission")
+		}
+	}
+}
+
+// PermitSome lets the request through when role of logged in user grants the permission in any scope,
+// must be used after Authorize. Handlers acting on several records check each one with permitted.
+func (h *Handler) PermitSome(perm entity.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, _ := c.Request().Context().Value(contextKeyUserRole).(string)
+			if rolePermissions[role][perm] != 0 {
+				return next(c)
+			}
+
+			return h.httpError(c, errors.ErrForbidden, "insufficient permission")
+		}
+	}
+}
+
+// permitted tells whether role of logged in user grants the permission on the record with id
+func permitted(c echo.Context, perm entity.Permission, id string) bool {
+	role, _ := c.Request().Context().Value(contextKeyUserRole).(string)
+	userId, _ := c.Request().Context().Value(contextKeyUserId).(string)
+
+	switch rolePermissions[role][perm] {
+	case scopeAny:
+		return true
+	case scopeOwn:
+		return userId != "" && id == userId
+	}
+
+	return false
+}
+
+func isAdmin(c echo.Context) bool {
+	role, _ := c.Request().Context().Value(contextKeyUserRole).(string)
+	return role == entity.RoleAdmin
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"api-gateway/config"
+	"api-gateway/entity"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func initTestHandler() *Handler {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return &Handler{config: &config.Value{}, logger: logger}
+}
+
+// newTestContext returns a request context as Authorize leaves it for the logged in user, with id as path param
+func newTestContext(role, userId, id string) (echo.Context, *httptest.ResponseRecorder) {
+	ctx := context.WithValue(context.Background(), contextKeyUserRole, role)
+	if userId != "" {
+		ctx = context.WithValue(ctx, contextKeyUserId, userId)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+
+	return c, rec
+}
+
+func TestPermit(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		userId string
+		id     string
+		perm   entity.Permission
+		// some uses PermitSome instead of Permit
+		some bool
+		want int
+	}{
+		{"admin on any user", entity.RoleAdmin, "admin", "other", entity.PermissionUserDelete, false, http.StatusOK},
+		{"user on own record", entity.RoleUser, "user", "user", entity.PermissionUserUpdate, false, http.StatusOK},
+		{"user on other record", entity.RoleUser, "user", "other", entity.PermissionUserUpdate, false, http.StatusForbidden},
+		{"user without own scope permission", entity.RoleUser, "user", "user", entity.PermissionUserDelete, false, http.StatusForbidden},
+		{"user without id", entity.RoleUser, "", "", entity.PermissionUserRead, false, http.StatusForbidden},
+		{"user without route id", entity.RoleUser, "user", "", entity.PermissionUserRead, false, http.StatusForbidden},
+		{"unknown role", "root", "user", "user", entity.PermissionUserRead, false, http.StatusForbidden},
+		{"missing role", "", "user", "user", entity.PermissionUserRead, false, http.StatusForbidden},
+		{"some with own scope", entity.RoleUser, "user", "", entity.PermissionUserRead, true, http.StatusOK},
+		{"some with any scope", entity.RoleAdmin, "admin", "", entity.PermissionUserList, true, http.StatusOK},
+		{"some without permission", entity.RoleUser, "user", "", entity.PermissionUserList, true, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := initTestHandler()
+			c, rec := newTestContext(tt.role, tt.userId, tt.id)
+
+			middleware := h.Permit(tt.perm)
+			if tt.some {
+				middleware = h.PermitSome(tt.perm)
+			}
+
+			err := middleware(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.want {
+				t.Fatalf("got status %v, want %v", rec.Code, tt.want)
+			}
+		})
+	}
+}
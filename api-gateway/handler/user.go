@@ -3,7 +3,12 @@ package handler
 import (
 	"api-gateway/entity"
 	"api-gateway/errors"
+	"encoding/json"
+	"fmt"
+	"mime"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -11,22 +16,107 @@ import (
 // ListUsers returns list of user
 //
 // @Summary Get user list
-// @Description Returns list of user
+// @Description Returns a page of users. Pass next_cursor of a page as cursor to get the following page.
 // @Tags users
 // @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Success 200 {object} entity.HttpResp{data=[]entity.User}
+// @Param limit query int false "page size" minimum(1) maximum(100) default(20)
+// @Param cursor query string false "next_cursor of previous page"
+// @Param sort query string false "sort order" Enums(created, -created, name, -name, email, -email) default(created)
+// @Param filter query string false "comma separated field:value pairs, fields are email, name (prefix), created_after and created_before (RFC3339)"
+// @Success 200 {object} entity.HttpResp{data=entity.UserPage}
 // @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
 // @Failure 500 {object} entity.HttpResp
 // @Router /v1/users [get]
 func (h *Handler) ListUsers(c echo.Context) error {
-	users, err := h.user.List(c.Request().Context())
+	return h.listUsers(c, false)
+}
+
+// ListDeletedUsers returns list of soft deleted user
+//
+// @Summary Get deleted user list
+// @Description Returns a page of soft deleted users that are not purged yet. Pass next_cursor of a page as cursor to get the following page.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param limit query int false "page size" minimum(1) maximum(100) default(20)
+// @Param cursor query string false "next_cursor of previous page"
+// @Param sort query string false "sort order" Enums(created, -created, name, -name, email, -email) default(created)
+// @Param filter query string false "comma separated field:value pairs, fields are email, name (prefix), created_after and created_before (RFC3339)"
+// @Success 200 {object} entity.HttpResp{data=entity.UserPage}
+// @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users/deleted [get]
+func (h *Handler) ListDeletedUsers(c echo.Context) error {
+	return h.listUsers(c, true)
+}
+
+func (h *Handler) listUsers(c echo.Context, deleted bool) error {
+	req := entity.UserListRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	query, err := parseUserFilter(req.Filter)
+	if err != nil {
+		return h.httpError(c, err)
+	}
+	query.Limit = req.Limit
+	query.Cursor = req.Cursor
+	query.Sort = req.Sort
+	query.Deleted = deleted
+
+	page, err := h.user.List(c.Request().Context(), query)
 	if err != nil {
 		return h.httpError(c, err)
 	}
 
-	return h.httpSuccess(c, http.StatusOK, users)
+	return h.httpSuccess(c, http.StatusOK, page)
+}
+
+// parseUserFilter reads filter query param formatted as field:value,field:value
+func parseUserFilter(filter string) (entity.UserQuery, error) {
+	query := entity.UserQuery{}
+	if filter == "" {
+		return query, nil
+	}
+
+	for _, pair := range strings.Split(filter, ",") {
+		field, value, ok := strings.Cut(pair, ":")
+		if !ok || value == "" {
+			return query, errors.NewFieldError("filter", fmt.Sprintf("%q is not formatted as field:value", pair))
+		}
+
+		switch field {
+		case "email":
+			query.Email = value
+		case "name":
+			query.NamePrefix = value
+		case "created_after", "created_before":
+			createdAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, errors.NewFieldError("filter", fmt.Sprintf("%v must be RFC3339 time", field))
+			}
+
+			if field == "created_after" {
+				query.CreatedAfter = createdAt
+			} else {
+				query.CreatedBefore = createdAt
+			}
+		default:
+			return query, errors.NewFieldError("filter", fmt.Sprintf("unknown field %q", field))
+		}
+	}
+
+	return query, nil
 }
 
 // CreateUser creates new user
@@ -40,6 +130,8 @@ func (h *Handler) ListUsers(c echo.Context) error {
 // @Param user body entity.UserCreateRequest true "user create request"
 // @Success 200 {object} entity.HttpResp{data=entity.User}
 // @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 409 {object} entity.HttpResp
 // @Failure 500 {object} entity.HttpResp
 // @Router /v1/users [post]
 func (h *Handler) CreateUser(c echo.Context) error {
@@ -55,6 +147,7 @@ func (h *Handler) CreateUser(c echo.Context) error {
 	user := entity.User{
 		Name:  req.Name,
 		Email: req.Email,
+		Role:  req.Role,
 	}
 	newUser, err := h.user.Create(c.Request().Context(), user)
 	if err != nil {
@@ -74,7 +167,10 @@ func (h *Handler) CreateUser(c echo.Context) error {
 // @Produce json
 // @Param id path string true "user id"
 // @Success 200 {object} entity.HttpResp{data=entity.User}
+// @Header 200 {string} ETag "version of the user"
 // @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 404 {object} entity.HttpResp
 // @Failure 500 {object} entity.HttpResp
 // @Router /v1/users/{id} [get]
 func (h *Handler) GetUser(c echo.Context) error {
@@ -91,22 +187,64 @@ func (h *Handler) GetUser(c echo.Context) error {
 	if err != nil {
 		return h.httpError(c, err)
 	}
+	setETag(c, user.Version)
 
 	return h.httpSuccess(c, http.StatusOK, user)
 }
 
-// UpdateUser returns logged in user detail
+// GetUserHistory returns changes made to specific user
 //
-// @Summary Get user detail
-// @Description Get logged in user detail
+// @Summary Get user change history
+// @Description Returns a page of changes made to user, newest first, with the changed fields and the user who made them. Changes made by the system have no actor_id. Pass next_cursor of a page as cursor to get the following page.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "user id"
+// @Param limit query int false "page size" minimum(1) maximum(100) default(20)
+// @Param cursor query string false "next_cursor of previous page"
+// @Success 200 {object} entity.HttpResp{data=entity.UserHistory}
+// @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users/{id}/history [get]
+func (h *Handler) GetUserHistory(c echo.Context) error {
+	req := entity.UserHistoryRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	history, err := h.user.History(c.Request().Context(), req.Id, req.Limit, req.Cursor)
+	if err != nil {
+		return h.httpError(c, err)
+	}
+
+	return h.httpSuccess(c, http.StatusOK, history)
+}
+
+// UpdateUser replaces existing user
+//
+// @Summary Replace user
+// @Description Replace name and email of existing user, role is kept unless given
 // @Tags users
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path string true "user id"
 // @Param user body entity.UserUpdateRequest true "user update request"
+// @Param If-Match header string false "ETag of the user, required when server enforces conditional requests"
 // @Success 200 {object} entity.HttpResp{data=entity.User}
+// @Header 200 {string} ETag "version of the user"
 // @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 404 {object} entity.HttpResp
+// @Failure 409 {object} entity.HttpResp
+// @Failure 412 {object} entity.HttpResp
+// @Failure 428 {object} entity.HttpResp
 // @Failure 500 {object} entity.HttpResp
 // @Router /v1/users/{id} [put]
 func (h *Handler) UpdateUser(c echo.Context) error {
@@ -119,15 +257,121 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 		return h.httpError(c, errors.ErrBadRequest, err.Error())
 	}
 
+	// only admins can change roles, including their own
+	if req.Role != "" && !isAdmin(c) {
+		return h.httpError(c, errors.ErrForbidden, "only admin can change role")
+	}
+
+	version, err := h.ifMatchVersion(c)
+	if err != nil {
+		return h.httpError(c, err)
+	}
+
 	user := entity.User{
-		Id:    req.Id,
-		Name:  req.Name,
-		Email: req.Email,
+		Id:      req.Id,
+		Name:    req.Name,
+		Email:   req.Email,
+		Role:    req.Role,
+		Version: version,
+	}
+
+	fields := []string{entity.UserFieldName, entity.UserFieldEmail}
+	if req.Role != "" {
+		fields = append(fields, entity.UserFieldRole)
+	}
+
+	user, err = h.user.Update(c.Request().Context(), user, fields...)
+	if err != nil {
+		return h.httpError(c, err)
+	}
+	setETag(c, user.Version)
+
+	return h.httpSuccess(c, http.StatusOK, user)
+}
+
+// PatchUser partially updates existing user
+//
+// @Summary Patch user
+// @Description Apply JSON merge patch (RFC 7386) to existing user, members left out are kept and members set to null are cleared
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "user id"
+// @Param user body entity.UserPatchRequest true "user merge patch"
+// @Param If-Match header string false "ETag of the user, required when server enforces conditional requests"
+// @Success 200 {object} entity.HttpResp{data=entity.User}
+// @Header 200 {string} ETag "version of the user"
+// @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 404 {object} entity.HttpResp
+// @Failure 409 {object} entity.HttpResp
+// @Failure 412 {object} entity.HttpResp
+// @Failure 415 {object} entity.HttpResp
+// @Failure 428 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users/{id} [patch]
+func (h *Handler) PatchUser(c echo.Context) error {
+	contentType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if contentType != "application/merge-patch+json" && contentType != echo.MIMEApplicationJSON {
+		return h.httpError(c, errors.ErrUnsupportedMedia, "use application/merge-patch+json")
+	}
+
+	req := entity.UserPatchRequest{}
+	decoder := json.NewDecoder(c.Request().Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	version, err := h.ifMatchVersion(c)
+	if err != nil {
+		return h.httpError(c, err)
+	}
+
+	user := entity.User{Id: c.Param("id"), Version: version}
+	fields := []string{}
+
+	if req.Name.Set {
+		user.Name = req.Name.Value
+		fields = append(fields, entity.UserFieldName)
+	}
+
+	if req.Email.Set {
+		user.Email = req.Email.Value
+		fields = append(fields, entity.UserFieldEmail)
+	}
+
+	if req.Role.Set {
+		// only admins can change roles, including their own
+		if !isAdmin(c) {
+			return h.httpError(c, errors.ErrForbidden, "only admin can change role")
+		}
+		user.Role = req.Role.Value
+		fields = append(fields, entity.UserFieldRole)
 	}
-	user, err := h.user.Update(c.Request().Context(), user)
+
+	// an empty patch changes nothing
+	if len(fields) == 0 {
+		current, err := h.user.Get(c.Request().Context(), entity.User{Id: user.Id})
+		if err != nil {
+			return h.httpError(c, err)
+		}
+
+		if version != 0 && current.Version != version {
+			return h.httpError(c, errors.ErrPreconditionFailed)
+		}
+		setETag(c, current.Version)
+
+		return h.httpSuccess(c, http.StatusOK, current)
+	}
+
+	user, err = h.user.Update(c.Request().Context(), user, fields...)
 	if err != nil {
 		return h.httpError(c, err)
 	}
+	setETag(c, user.Version)
 
 	return h.httpSuccess(c, http.StatusOK, user)
 }
@@ -141,8 +385,13 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "user id"
+// @Param If-Match header string false "ETag of the user, required when server enforces conditional requests"
 // @Success 200 {object} entity.HttpResp
 // @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 404 {object} entity.HttpResp
+// @Failure 412 {object} entity.HttpResp
+// @Failure 428 {object} entity.HttpResp
 // @Failure 500 {object} entity.HttpResp
 // @Router /v1/users/{id} [delete]
 func (h *Handler) DeleteUser(c echo.Context) error {
@@ -155,7 +404,123 @@ func (h *Handler) DeleteUser(c echo.Context) error {
 		return h.httpError(c, errors.ErrBadRequest, err.Error())
 	}
 
-	if err := h.user.Delete(c.Request().Context(), entity.User{Id: req.Id}); err != nil {
+	version, err := h.ifMatchVersion(c)
+	if err != nil {
+		return h.httpError(c, err)
+	}
+
+	if err := h.user.Delete(c.Request().Context(), entity.User{Id: req.Id, Version: version}); err != nil {
+		return h.httpError(c, err)
+	}
+
+	return h.httpSuccess(c, http.StatusOK, nil)
+}
+
+// RestoreUser brings back soft deleted user
+//
+// @Summary Restore user
+// @Description Restore soft deleted user that is not purged yet
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "user id"
+// @Success 200 {object} entity.HttpResp{data=entity.User}
+// @Header 200 {string} ETag "version of the user"
+// @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 404 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users/{id}/restore [post]
+func (h *Handler) RestoreUser(c echo.Context) error {
+	req := entity.UserGetRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, err)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	user, err := h.user.Restore(c.Request().Context(), entity.User{Id: req.Id})
+	if err != nil {
+		return h.httpError(c, err)
+	}
+	setETag(c, user.Version)
+
+	return h.httpSuccess(c, http.StatusOK, user)
+}
+
+// RevokeUserSessions revokes every session of existing user
+//
+// @Summary Revoke user sessions
+// @Description Revoke every access and refresh token of existing user
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "user id"
+// @Success 200 {object} entity.HttpResp
+// @Failure 400 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users/{id}/sessions [delete]
+func (h *Handler) RevokeUserSessions(c echo.Context) error {
+	req := entity.UserGetRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, err)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	if err := h.token.RevokeUser(c.Request().Context(), req.Id); err != nil {
+		return h.httpError(c, err)
+	}
+
+	return h.httpSuccess(c, http.StatusOK, nil)
+}
+
+// UpdatePassword changes password of existing user
+//
+// @Summary Update user password
+// @Description Change password of logged in user after checking the old one. Admin may leave old password empty to reset password of any user. Every session of the user is revoked afterwards.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "user id"
+// @Param password body entity.PasswordUpdateRequest true "password update request"
+// @Success 200 {object} entity.HttpResp
+// @Failure 400 {object} entity.HttpResp
+// @Failure 401 {object} entity.HttpResp
+// @Failure 403 {object} entity.HttpResp
+// @Failure 404 {object} entity.HttpResp
+// @Failure 500 {object} entity.HttpResp
+// @Router /v1/users/{id}/password [put]
+func (h *Handler) UpdatePassword(c echo.Context) error {
+	req := entity.PasswordUpdateRequest{}
+	if err := c.Bind(&req); err != nil {
+		return h.httpError(c, err)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return h.httpError(c, errors.ErrBadRequest, err.Error())
+	}
+
+	if req.OldPassword == "" && isAdmin(c) {
+		if err := h.user.SetPassword(c.Request().Context(), req.Id, req.NewPassword); err != nil {
+			return h.httpError(c, err)
+		}
+
+		return h.httpSuccess(c, http.StatusOK, nil)
+	}
+
+	err := h.user.ChangePassword(c.Request().Context(), req.Id, req.OldPassword, req.NewPassword)
+	if errors.Is(err, errors.ErrUnauthorized) {
+		return h.httpError(c, err, "old password does not match")
+	} else if err != nil {
 		return h.httpError(c, err)
 	}
 
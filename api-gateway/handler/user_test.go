@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"api-gateway/entity"
+	"api-gateway/usecase"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// fakeUserUsecase records the update it is asked for, current is the stored user
+type fakeUserUsecase struct {
+	usecase.UserInterface
+	current entity.User
+	updated *entity.User
+	fields  []string
+}
+
+func (f *fakeUserUsecase) Get(ctx context.Context, filter entity.User) (entity.User, error) {
+	return f.current, nil
+}
+
+func (f *fakeUserUsecase) Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error) {
+	updated := user
+	f.updated, f.fields = &updated, fields
+	user.Version = f.current.Version + 1
+
+	return user, nil
+}
+
+func TestPatchUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		contentType string
+		body        string
+		want        int
+		// wantFields are the fields updated, nil when nothing is updated
+		wantFields []string
+		wantUser   entity.User
+	}{
+		{
+			name:       "member set",
+			body:       `{"name":"jane"}`,
+			want:       http.StatusOK,
+			wantFields: []string{entity.UserFieldName},
+			wantUser:   entity.User{Id: "user", Name: "jane"},
+		},
+		{
+			name:       "member set to null is cleared",
+			body:       `{"name":"jane","email":null}`,
+			want:       http.StatusOK,
+			wantFields: []string{entity.UserFieldName, entity.UserFieldEmail},
+			wantUser:   entity.User{Id: "user", Name: "jane"},
+		},
+		{
+			name:        "merge patch media type",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			body:        `{"email":"jane@example.com"}`,
+			want:        http.StatusOK,
+			wantFields:  []string{entity.UserFieldEmail},
+			wantUser:    entity.User{Id: "user", Email: "jane@example.com"},
+		},
+		{
+			name: "empty patch changes nothing",
+			body: `{}`,
+			want: http.StatusOK,
+		},
+		{
+			name:       "role by admin",
+			role:       entity.RoleAdmin,
+			body:       `{"role":"admin"}`,
+			want:       http.StatusOK,
+			wantFields: []string{entity.UserFieldRole},
+			wantUser:   entity.User{Id: "user", Role: entity.RoleAdmin},
+		},
+		{
+			name: "role by user",
+			body: `{"role":"admin"}`,
+			want: http.StatusForbidden,
+		},
+		{
+			name: "unknown member",
+			body: `{"password":"secret"}`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "member of wrong type",
+			body: `{"name":1}`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "malformed body",
+			body: `{"name":`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name:        "other media type",
+			contentType: echo.MIMETextPlain,
+			body:        `{"name":"jane"}`,
+			want:        http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role := tt.role
+			if role == "" {
+				role = entity.RoleUser
+			}
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = echo.MIMEApplicationJSON
+			}
+
+			user := &fakeUserUsecase{current: entity.User{Id: "user", Name: "old", Version: 3}}
+			h := initTestHandler()
+			h.user = user
+
+			c, rec := newTestContext(role, "user", "user")
+			req := httptest.NewRequest(http.MethodPatch, "/v1/users/user", strings.NewReader(tt.body)).WithContext(c.Request().Context())
+			req.Header.Set(echo.HeaderContentType, contentType)
+			c.SetRequest(req)
+
+			if err := h.PatchUser(c); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.want {
+				t.Fatalf("got status %v, want %v: %v", rec.Code, tt.want, rec.Body.String())
+			}
+			if !reflect.DeepEqual(user.fields, tt.wantFields) {
+				t.Fatalf("got fields %v updated, want %v", user.fields, tt.wantFields)
+			}
+			if tt.wantFields != nil && *user.updated != tt.wantUser {
+				t.Fatalf("got %+v updated, want %+v", *user.updated, tt.wantUser)
+			}
+		})
+	}
+}
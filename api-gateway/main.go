@@ -2,18 +2,32 @@ package main
 
 import (
 	"account-service/grpc"
+	accountv1 "account-service/grpc/account/v1"
+	"account-service/tracing"
 	"api-gateway/config"
 	"api-gateway/docs"
 	"api-gateway/domain"
+	"api-gateway/entity"
+	"api-gateway/errors"
 	"api-gateway/handler"
 	"api-gateway/usecase"
+	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 )
 
 // @contact.name Nafisa Alfiani
@@ -35,24 +49,35 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	logger.Info(fmt.Sprintf("%#v", cfg))
+	logger.WithField("config", cfg).Info("config loaded")
+
+	// init tracer
+	tracer, err := tracing.Init(cfg.Trace, "api-gateway")
+	if err != nil {
+		log.Fatalln(err)
+	}
 
 	// init validator
 	validator := validator.New(validator.WithRequiredStructEnabled())
 
 	// init grpc procedure
-	cc, err := grpc.Dial(fmt.Sprintf("%v:%v", cfg.GrpcServer.Base, cfg.GrpcServer.Port), grpc.WithInsecure())
+	cc, err := grpc.Dial(fmt.Sprintf("%v:%v", cfg.GrpcServer.Base, cfg.GrpcServer.Port), grpc.WithInsecure(), grpc.WithStatsHandler(otelgrpc.NewClientHandler()), grpc.WithUnaryInterceptor(errors.UnaryClientInterceptor), grpc.WithStreamInterceptor(errors.StreamClientInterceptor), grpc.WithUnaryInterceptor(domain.RequestIdUnaryClientInterceptor), grpc.WithStreamInterceptor(domain.RequestIdStreamClientInterceptor))
 	if err != nil {
 		log.Fatalln(err)
 	}
 	defer cc.Close()
-	userClient := grpc.NewUserServiceClient(cc)
+	userClient := accountv1.NewUserServiceClient(cc)
+	tokenClient := grpc.NewTokenServiceClient(cc)
 
 	// init domain
-	dom := domain.Init(logger, userClient)
+	dom := domain.Init(logger, userClient, tokenClient)
 
 	// init usecase
-	usecase := usecase.Init(cfg, logger, dom)
+	usecase, err := usecase.Init(cfg, logger, dom)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	go usecase.Key.RotateEvery(cfg.Auth.KeyRotationInterval)
 
 	// init handler
 	handler := handler.Init(cfg, usecase, validator, logger)
@@ -61,23 +86,101 @@ func main() {
 	e := echo.New()
 
 	e.Use(middleware.Recover())
+	e.Use(otelecho.Middleware("api-gateway"))
+	e.Use(handler.MiddlewareRequestId)
 	e.Use(handler.MiddlewareLogging)
-	e.Use(middleware.CORSWithConfig(middleware.DefaultCORSConfig))
+	e.Use(handler.MiddlewareMetrics)
+	corsConfig := middleware.DefaultCORSConfig
+	corsConfig.ExposeHeaders = []string{"ETag", echo.HeaderXRequestID}
+	e.Use(middleware.CORSWithConfig(corsConfig))
 
 	docs.SwaggerInfo.Title = "API Gateway"
 	e.GET("/swagger/*", echoSwagger.EchoWrapHandler())
 	e.GET("/ping", handler.Ping)
+	e.GET("/.well-known/jwks.json", handler.JWKS)
 
 	api := e.Group("/api")
 	api.POST("/register", handler.Register)
 	api.POST("/login", handler.Login)
+	api.POST("/token/refresh", handler.RefreshToken)
+	api.POST("/logout", handler.Logout, handler.Authorize)
 
 	users := api.Group("/users", handler.Authorize)
-	users.GET("", handler.ListUsers)
-	users.POST("", handler.CreateUser)
-	users.GET("/:id", handler.GetUser)
-	users.PUT("/:id", handler.UpdateUser)
-	users.DELETE("/:id", handler.DeleteUser)
+	users.GET("", handler.ListUsers, handler.Permit(entity.PermissionUserList))
+	users.POST("", handler.CreateUser, handler.Permit(entity.PermissionUserCreate))
+	users.GET("/deleted", handler.ListDeletedUsers, handler.Permit(entity.PermissionUserListDeleted))
+	users.GET("/export", handler.ExportUsers, handler.Permit(entity.PermissionUserExport))
+	users.POST("/import", handler.ImportUsers, handler.Permit(entity.PermissionUserImport))
+	// colons are escaped so that echo does not read them as path params
+	users.POST("\\:batchGet", handler.BatchGetUsers, handler.PermitSome(entity.PermissionUserRead))
+	users.POST("\\:batchDelete", handler.BatchDeleteUsers, handler.PermitSome(entity.PermissionUserDelete))
+	users.GET("/:id", handler.GetUser, handler.Permit(entity.PermissionUserRead))
+	users.GET("/:id/history", handler.GetUserHistory, handler.Permit(entity.PermissionUserReadHistory))
+	users.PUT("/:id", handler.UpdateUser, handler.Permit(entity.PermissionUserUpdate))
+	users.PATCH("/:id", handler.PatchUser, handler.Permit(entity.PermissionUserUpdate))
+	users.PUT("/:id/password", handler.UpdatePassword, handler.Permit(entity.PermissionUserUpdatePassword))
+	users.DELETE("/:id", handler.DeleteUser, handler.Permit(entity.PermissionUserDelete))
+	users.POST("/:id/restore", handler.RestoreUser, handler.Permit(entity.PermissionUserRestore))
+	users.DELETE("/:id/sessions", handler.RevokeUserSessions, handler.Permit(entity.PermissionUserRevokeSessions))
+
+	go func() {
+		if err := e.Start(fmt.Sprintf("%v:%v", cfg.Server.Base, cfg.Server.Port)); err != nil && err != http.ErrServerClosed {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	admin := serveAdmin(cfg, logger)
+
+	// stop on SIGTERM or interrupt, flushing spans that are not exported yet
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("failed to shut down server. %v", err)
+	}
+	// metrics stay up while requests drain, the admin listener gets a deadline of its own
+	if admin != nil {
+		adminCtx, cancel := context.WithTimeout(context.Background(), adminShutdownTimeout)
+		defer cancel()
+
+		if err := admin.Shutdown(adminCtx); err != nil {
+			logger.Errorf("failed to shut down admin listener. %v", err)
+		}
+	}
+	if err := tracer.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("failed to flush spans. %v", err)
+	}
+}
+
+// adminShutdownTimeout bounds closing the admin listener, a scrape in flight is not worth waiting longer for
+const adminShutdownTimeout = 5 * time.Second
+
+// serveAdmin serves prometheus metrics on the admin port so that they are never exposed next to the public api,
+// it returns nil when the admin port is not set
+func serveAdmin(cfg *config.Value, logger *logrus.Logger) *http.Server {
+	if cfg.Server.AdminPort == 0 {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	admin := &http.Server{
+		Addr:              fmt.Sprintf("%v:%v", cfg.Server.Base, cfg.Server.AdminPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := admin.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal(err)
+		}
+	}()
+	logger.Infof("Listening and Serving admin HTTP on %v", admin.Addr)
 
-	e.Logger.Fatal(e.Start(fmt.Sprintf("%v:%v", cfg.Server.Base, cfg.Server.Port)))
+	return admin
 }
@@ -0,0 +1,11 @@
+package usecase
+
+import (
+	"api-gateway/domain"
+	"context"
+)
+
+// WithActor returns a context whose changes are recorded in user history as made by the given user
+func WithActor(ctx context.Context, actorId string) context.Context {
+	return domain.WithActor(ctx, actorId)
+}
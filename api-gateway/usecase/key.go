@@ -0,0 +1,403 @@
+package usecase
+
+import (
+	"api-gateway/config"
+	"api-gateway/entity"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/sirupsen/logrus"
+)
+
+// key signs with the newest key of AUTH_KEYS_DIR, which may be shared by every gateway instance,
+// so that a token signed by one instance verifies on all of them and JWKS lists the same keys
+type key struct {
+	cfg    *config.Value
+	logger *logrus.Logger
+
+	mu         sync.RWMutex
+	current    *signingKey
+	keys       map[string]*signingKey
+	lastReload time.Time
+}
+
+type signingKey struct {
+	kid       string
+	private   crypto.Signer
+	createdAt time.Time
+	retiredAt time.Time
+}
+
+type KeyInterface interface {
+	Sign(claims jwt.MapClaims) (string, error)
+	Parse(tokenString string) (*jwt.Token, error)
+	JWKS() entity.JWKS
+	Rotate() error
+	RotateEvery(interval time.Duration)
+}
+
+// initKey creates signing key usecase, loading persisted keys from AUTH_KEYS_DIR when set
+func initKey(cfg *config.Value, logger *logrus.Logger) (KeyInterface, error) {
+	k := &key{
+		cfg:    cfg,
+		logger: logger,
+		keys:   map[string]*signingKey{},
+	}
+
+	if err := k.reload(); err != nil {
+		return nil, err
+	}
+
+	if k.due(cfg.Auth.KeyRotationInterval) {
+		if err := k.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// Sign signs claims with current key, putting its id in kid header
+func (k *key) Sign(claims jwt.MapClaims) (string, error) {
+	k.mu.RLock()
+	current := k.current
+	k.mu.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.cfg.Auth.SigningAlg), claims)
+	token.Header["kid"] = current.kid
+
+	return token.SignedString(current.private)
+}
+
+// Parse verifies token against the key named by its kid header, rejecting any other algorithm
+func (k *key) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != k.cfg.Auth.SigningAlg {
+			return nil, fmt.Errorf("unexpected signing algorithm %v", token.Method.Alg())
+		}
+
+		kid, _ := token.Header["kid"].(string)
+
+		signer, ok := k.lookup(kid)
+		if !ok && k.reloadForUnknownKid() {
+			// another instance may have rotated since the directory was last read
+			signer, ok = k.lookup(kid)
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+
+		return signer.private.Public(), nil
+	})
+}
+
+// JWKS returns public keys of every key whose tokens may still be valid
+func (k *key) JWKS() entity.JWKS {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := entity.JWKS{Keys: []entity.JWK{}}
+	for _, signer := range k.keys {
+		jwk := publicJWK(signer.private.Public())
+		jwk.Kid = signer.kid
+		jwk.Use = "sig"
+		jwk.Alg = k.cfg.Auth.SigningAlg
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
+
+func (k *key) lookup(kid string) (*signingKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	signer, ok := k.keys[kid]
+	return signer, ok
+}
+
+// reloadForUnknownKid reads the key directory again unless it was read within the last second,
+// so tokens with made up kids can not make every request hit the disk
+func (k *key) reloadForUnknownKid() bool {
+	k.mu.RLock()
+	recent := time.Since(k.lastReload) < time.Second
+	k.mu.RUnlock()
+
+	if k.cfg.Auth.KeysDir == "" || recent {
+		return false
+	}
+
+	if err := k.reload(); err != nil {
+		k.logger.Error(err)
+		return false
+	}
+
+	return true
+}
+
+// due reports whether current key is older than interval, a zero interval never rotates once a key exists
+func (k *key) due(interval time.Duration) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.current == nil || (interval > 0 && time.Since(k.current.createdAt) >= interval)
+}
+
+// Rotate generates a new current key, previous one is kept for verification until its tokens expire
+func (k *key) Rotate() error {
+	private, err := k.generate()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	signer := &signingKey{
+		kid:       thumbprint(private.Public()),
+		private:   private,
+		createdAt: now,
+	}
+
+	if err := k.save(signer); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.current != nil {
+		k.current.retiredAt = now
+	}
+	k.keys[signer.kid] = signer
+	k.current = signer
+	k.dropExpired(now)
+
+	k.logger.Infof("rotated signing key, current key id %v", signer.kid)
+
+	return nil
+}
+
+// RotateEvery rotates signing key once it is older than interval, it blocks so run it in its own goroutine.
+// With AUTH_KEYS_DIR set the directory is read again on every AUTH_KEYS_RELOAD_INTERVAL, so a key rotated
+// by any instance is adopted by all of them and the others skip their own rotation
+func (k *key) RotateEvery(interval time.Duration) {
+	tick := interval
+	if k.cfg.Auth.KeysDir != "" {
+		tick = k.cfg.Auth.KeysReloadInterval
+	}
+	if tick <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := k.reload(); err != nil {
+			k.logger.Error(err)
+			continue
+		}
+
+		if interval > 0 && k.due(interval) {
+			if err := k.Rotate(); err != nil {
+				k.logger.Error(err)
+			}
+		}
+	}
+}
+
+func (k *key) generate() (crypto.Signer, error) {
+	if k.cfg.Auth.SigningAlg == "ES256" {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+
+	return rsa.GenerateKey(rand.Reader, 2048)
+}
+
+// reload replaces known keys with those of the key directory, the newest one signs
+func (k *key) reload() error {
+	if k.cfg.Auth.KeysDir == "" {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(k.cfg.Auth.KeysDir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	signers := []*signingKey{}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		block, _ := pem.Decode(raw)
+		if block == nil {
+			return fmt.Errorf("no pem data found in %v", file)
+		}
+
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse %v: %w", file, err)
+		}
+
+		private, ok := parsed.(crypto.Signer)
+		if !ok || !k.matchesAlg(private) {
+			k.logger.Warnf("skipping key %v, it can not be used with %v", file, k.cfg.Auth.SigningAlg)
+			continue
+		}
+
+		signers = append(signers, &signingKey{
+			kid:       strings.TrimSuffix(filepath.Base(file), ".pem"),
+			private:   private,
+			createdAt: info.ModTime(),
+		})
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.lastReload = time.Now()
+
+	// an emptied directory must not leave this instance without a key to sign with
+	if len(signers) == 0 {
+		return nil
+	}
+
+	// newest key signs, each older key was retired when its successor was created
+	sort.Slice(signers, func(i, j int) bool {
+		return signers[i].createdAt.Before(signers[j].createdAt)
+	})
+	keys := map[string]*signingKey{}
+	for i, signer := range signers {
+		if i+1 < len(signers) {
+			signer.retiredAt = signers[i+1].createdAt
+		}
+		keys[signer.kid] = signer
+		k.current = signer
+	}
+	k.keys = keys
+	k.dropExpired(k.lastReload)
+
+	return nil
+}
+
+// dropExpired forgets keys retired longer than an access token lives, along with their files.
+// Whichever instance notices first removes a file, its creator may never rotate again, k.mu must be held
+func (k *key) dropExpired(now time.Time) {
+	for kid, old := range k.keys {
+		if old.retiredAt.IsZero() || now.Sub(old.retiredAt) <= k.cfg.Auth.AccessTokenTTL {
+			continue
+		}
+
+		delete(k.keys, kid)
+
+		if k.cfg.Auth.KeysDir != "" {
+			// another instance may have removed it first
+			if err := os.Remove(filepath.Join(k.cfg.Auth.KeysDir, kid+".pem")); err != nil && !os.IsNotExist(err) {
+				k.logger.Warnf("failed to remove retired key %v: %v", kid, err)
+			}
+		}
+	}
+}
+
+func (k *key) save(signer *signingKey) error {
+	if k.cfg.Auth.KeysDir == "" {
+		return nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer.private)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(k.cfg.Auth.KeysDir, 0700); err != nil {
+		return err
+	}
+
+	file := filepath.Join(k.cfg.Auth.KeysDir, signer.kid+".pem")
+	return os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+}
+
+func (k *key) matchesAlg(private crypto.Signer) bool {
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		return k.cfg.Auth.SigningAlg == "RS256"
+	case *ecdsa.PrivateKey:
+		return k.cfg.Auth.SigningAlg == "ES256" && private.Curve == elliptic.P256()
+	default:
+		return false
+	}
+}
+
+func publicJWK(public crypto.PublicKey) entity.JWK {
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		return entity.JWK{
+			Kty: "RSA",
+			N:   encodeBase64(public.N),
+			E:   encodeBase64(big.NewInt(int64(public.E))),
+		}
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		return entity.JWK{
+			Kty: "EC",
+			Crv: public.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size))),
+		}
+	default:
+		return entity.JWK{}
+	}
+}
+
+// thumbprint returns RFC 7638 thumbprint of public key, used as its kid
+func thumbprint(public crypto.PublicKey) string {
+	jwk := publicJWK(public)
+
+	// members must be in lexicographic order, which json.Marshal does for maps
+	members := map[string]string{"kty": jwk.Kty}
+	if jwk.Kty == "RSA" {
+		members["n"] = jwk.N
+		members["e"] = jwk.E
+	} else {
+		members["crv"] = jwk.Crv
+		members["x"] = jwk.X
+		members["y"] = jwk.Y
+	}
+
+	raw, _ := json.Marshal(members)
+	sum := sha256.Sum256(raw)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func encodeBase64(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
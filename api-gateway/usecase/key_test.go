@@ -0,0 +1,276 @@
+package usecase
+
+import (
+	"api-gateway/config"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/sirupsen/logrus"
+)
+
+func initTestKey(t *testing.T, auth config.Auth) *key {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	k, err := initKey(&config.Value{Auth: auth}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k.(*key)
+}
+
+func generateEcKey(t *testing.T) crypto.Signer {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return private
+}
+
+func TestParse(t *testing.T) {
+	claims := jwt.MapClaims{"user_id": "user", "exp": time.Now().Add(time.Hour).Unix()}
+
+	// sign signs claims as an attacker could, with any method, key and kid
+	sign := func(t *testing.T, method jwt.SigningMethod, private any, kid string) string {
+		t.Helper()
+
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+
+		signed, err := token.SignedString(private)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return signed
+	}
+
+	tests := []struct {
+		name string
+		// token returns the token to parse, signed before or after rotation
+		token  func(t *testing.T, k *key) string
+		rotate bool
+		valid  bool
+	}{
+		{
+			name: "signed with current key",
+			token: func(t *testing.T, k *key) string {
+				signed, err := k.Sign(claims)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return signed
+			},
+			valid: true,
+		},
+		{
+			name: "signed with retired key",
+			token: func(t *testing.T, k *key) string {
+				signed, err := k.Sign(claims)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return signed
+			},
+			rotate: true,
+			valid:  true,
+		},
+		{
+			name: "other algorithm under current kid",
+			token: func(t *testing.T, k *key) string {
+				private, err := rsa.GenerateKey(rand.Reader, 2048)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return sign(t, jwt.SigningMethodRS256, private, k.current.kid)
+			},
+		},
+		{
+			name: "hmac keyed with the public key",
+			token: func(t *testing.T, k *key) string {
+				public, err := x509.MarshalPKIXPublicKey(k.current.private.Public())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return sign(t, jwt.SigningMethodHS256, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), k.current.kid)
+			},
+		},
+		{
+			name: "unsigned",
+			token: func(t *testing.T, k *key) string {
+				return sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, k.current.kid)
+			},
+		},
+		{
+			name: "other key under current kid",
+			token: func(t *testing.T, k *key) string {
+				return sign(t, jwt.SigningMethodES256, generateEcKey(t), k.current.kid)
+			},
+		},
+		{
+			name: "unknown kid",
+			token: func(t *testing.T, k *key) string {
+				return sign(t, jwt.SigningMethodES256, generateEcKey(t), "unknown")
+			},
+		},
+		{
+			name: "missing kid",
+			token: func(t *testing.T, k *key) string {
+				return sign(t, jwt.SigningMethodES256, k.current.private, "")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := initTestKey(t, config.Auth{SigningAlg: "ES256", AccessTokenTTL: time.Hour})
+
+			signed := tt.token(t, k)
+			if tt.rotate {
+				if err := k.Rotate(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			token, err := k.Parse(signed)
+			if valid := err == nil && token.Valid; valid != tt.valid {
+				t.Fatalf("got valid %v (%v), want %v", valid, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	tests := []struct {
+		alg string
+		kty string
+	}{
+		{"ES256", "EC"},
+		{"RS256", "RSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			k := initTestKey(t, config.Auth{SigningAlg: tt.alg, AccessTokenTTL: time.Hour})
+			first := k.current.kid
+			if err := k.Rotate(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := []string{first, k.current.kid}
+			sort.Strings(want)
+
+			jwks := k.JWKS()
+			if len(jwks.Keys) != len(want) {
+				t.Fatalf("got %v keys, want %v", len(jwks.Keys), len(want))
+			}
+			for i, jwk := range jwks.Keys {
+				if jwk.Kid != want[i] || jwk.Kty != tt.kty || jwk.Alg != tt.alg || jwk.Use != "sig" {
+					t.Fatalf("got key %+v, want kid %v of type %v for %v signatures", jwk, want[i], tt.kty, tt.alg)
+				}
+			}
+
+			// the kid is the thumbprint of the published key, so clients can check one against the other
+			if kid := thumbprint(k.current.private.Public()); kid != k.current.kid {
+				t.Fatalf("got kid %v, want thumbprint %v", k.current.kid, kid)
+			}
+		})
+	}
+}
+
+func TestKeyRetirement(t *testing.T) {
+	tests := []struct {
+		name string
+		// ages are how long ago each key file was written, the newest one signs
+		ages []time.Duration
+		kept []bool
+	}{
+		{
+			name: "only key is never retired",
+			ages: []time.Duration{3 * time.Hour},
+			kept: []bool{true},
+		},
+		{
+			name: "retired within token ttl",
+			ages: []time.Duration{3 * time.Hour, 30 * time.Minute},
+			kept: []bool{true, true},
+		},
+		{
+			name: "retired longer than token ttl",
+			ages: []time.Duration{3 * time.Hour, 2 * time.Hour},
+			kept: []bool{false, true},
+		},
+		{
+			name: "only keys retired too long ago",
+			ages: []time.Duration{4 * time.Hour, 3 * time.Hour, 30 * time.Minute, 10 * time.Minute},
+			kept: []bool{false, true, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			// the files are written the way other instances would, this one never created them
+			kids := make([]string, len(tt.ages))
+			for i, age := range tt.ages {
+				kids[i] = writeKeyFile(t, dir, generateEcKey(t), time.Now().Add(-age))
+			}
+
+			k := initTestKey(t, config.Auth{SigningAlg: "ES256", KeysDir: dir, AccessTokenTTL: time.Hour})
+
+			if newest := kids[len(kids)-1]; k.current.kid != newest {
+				t.Fatalf("got current key %v, want newest %v", k.current.kid, newest)
+			}
+
+			for i, kid := range kids {
+				_, known := k.lookup(kid)
+				_, err := os.Stat(filepath.Join(dir, kid+".pem"))
+				if exists := err == nil; known != tt.kept[i] || exists != tt.kept[i] {
+					t.Fatalf("key %v: got known %v and file %v, want both %v", i, known, exists, tt.kept[i])
+				}
+			}
+		})
+	}
+}
+
+// writeKeyFile stores private in dir as if it was created at createdAt, returning its kid
+func writeKeyFile(t *testing.T, dir string, private crypto.Signer, createdAt time.Time) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kid := thumbprint(private.Public())
+	file := filepath.Join(dir, kid+".pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, createdAt, createdAt); err != nil {
+		t.Fatal(err)
+	}
+
+	return kid
+}
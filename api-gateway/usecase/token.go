@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"api-gateway/config"
+	"api-gateway/domain"
+	"api-gateway/entity"
+	"context"
+	"sync"
+	"time"
+)
+
+type token struct {
+	cfg   *config.Value
+	token domain.TokenInterface
+
+	// revocation results are cached locally, so a token revoked through
+	// another gateway instance is honoured here after RevocationCacheTTL
+	mu        sync.Mutex
+	lastSweep time.Time
+	tokens    map[string]revocation
+	users     map[string]time.Time
+}
+
+type revocation struct {
+	revoked bool
+	until   time.Time
+}
+
+type TokenInterface interface {
+	Issue(ctx context.Context, userId string) (entity.RefreshToken, error)
+	Check(ctx context.Context, refreshToken string) (entity.RefreshToken, error)
+	Rotate(ctx context.Context, refreshToken string) (entity.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
+	RevokeAccessToken(ctx context.Context, accessToken entity.AccessToken) error
+	RevokeUser(ctx context.Context, userId string) error
+	IsRevoked(ctx context.Context, accessToken entity.AccessToken) (bool, error)
+}
+
+// initToken creates token usecase
+func initToken(cfg *config.Value, tokenDom domain.TokenInterface) TokenInterface {
+	return &token{
+		cfg:       cfg,
+		token:     tokenDom,
+		lastSweep: time.Now(),
+		tokens:    map[string]revocation{},
+		users:     map[string]time.Time{},
+	}
+}
+
+func (t *token) Issue(ctx context.Context, userId string) (entity.RefreshToken, error) {
+	return t.token.Issue(ctx, userId)
+}
+
+func (t *token) Check(ctx context.Context, refreshToken string) (entity.RefreshToken, error) {
+	return t.token.Check(ctx, refreshToken)
+}
+
+func (t *token) Rotate(ctx context.Context, refreshToken string) (entity.RefreshToken, error) {
+	return t.token.Rotate(ctx, refreshToken)
+}
+
+func (t *token) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	return t.token.RevokeRefreshToken(ctx, refreshToken)
+}
+
+func (t *token) RevokeAccessToken(ctx context.Context, accessToken entity.AccessToken) error {
+	if err := t.token.RevokeAccessToken(ctx, accessToken); err != nil {
+		return err
+	}
+
+	t.remember(accessToken.Jti, true, accessToken.ExpiresAt)
+
+	return nil
+}
+
+func (t *token) RevokeUser(ctx context.Context, userId string) error {
+	if err := t.token.RevokeUser(ctx, userId); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.users[userId] = time.Now()
+	t.mu.Unlock()
+
+	return nil
+}
+
+// IsRevoked checks access token against local cache before asking account-service
+func (t *token) IsRevoked(ctx context.Context, accessToken entity.AccessToken) (bool, error) {
+	if revoked, ok := t.cached(accessToken); ok {
+		return revoked, nil
+	}
+
+	revoked, err := t.token.IsRevoked(ctx, accessToken)
+	if err != nil {
+		return false, err
+	}
+
+	t.remember(accessToken.Jti, revoked, time.Now().Add(t.cfg.Auth.RevocationCacheTTL))
+
+	return revoked, nil
+}
+
+func (t *token) cached(accessToken entity.AccessToken) (bool, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// iat has whole seconds, a token issued in the second of the revocation is revoked too
+	if revokedAt, ok := t.users[accessToken.UserId]; ok && !accessToken.IssuedAt.After(revokedAt) {
+		return true, true
+	}
+
+	entry, ok := t.tokens[accessToken.Jti]
+	if !ok || time.Now().After(entry.until) {
+		return false, false
+	}
+
+	return entry.revoked, true
+}
+
+func (t *token) remember(jti string, revoked bool, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tokens[jti] = revocation{
+		revoked: revoked,
+		until:   until,
+	}
+
+	now := time.Now()
+	if now.Sub(t.lastSweep) < t.cfg.Auth.RevocationCacheTTL {
+		return
+	}
+	t.lastSweep = now
+
+	for jti, entry := range t.tokens {
+		if now.After(entry.until) {
+			delete(t.tokens, jti)
+		}
+	}
+
+	// every token issued before user revocation has expired by now
+	for userId, revokedAt := range t.users {
+		if now.Sub(revokedAt) > t.cfg.Auth.AccessTokenTTL {
+			delete(t.users, userId)
+		}
+	}
+}
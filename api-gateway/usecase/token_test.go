@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"api-gateway/config"
+	"api-gateway/domain"
+	"api-gateway/entity"
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeTokenDomain answers revocation checks as account-service would, counting how often it is asked
+type fakeTokenDomain struct {
+	domain.TokenInterface
+	revoked bool
+	checks  int
+}
+
+func (f *fakeTokenDomain) IsRevoked(ctx context.Context, accessToken entity.AccessToken) (bool, error) {
+	f.checks++
+	return f.revoked, nil
+}
+
+func (f *fakeTokenDomain) RevokeAccessToken(ctx context.Context, accessToken entity.AccessToken) error {
+	return nil
+}
+
+func (f *fakeTokenDomain) RevokeUser(ctx context.Context, userId string) error {
+	return nil
+}
+
+func TestIsRevoked(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		cacheTTL time.Duration
+		// revoked is what account-service answers
+		revoked bool
+		before  func(ctx context.Context, tok TokenInterface)
+		// issuedAt is how long before now the token was issued, iat has whole seconds
+		issuedAt   time.Duration
+		want       bool
+		wantChecks int
+	}{
+		{
+			name:       "valid token is cached",
+			cacheTTL:   time.Minute,
+			want:       false,
+			wantChecks: 1,
+		},
+		{
+			name:       "revoked token is cached",
+			cacheTTL:   time.Minute,
+			revoked:    true,
+			want:       true,
+			wantChecks: 1,
+		},
+		{
+			name:       "expired cache asks again",
+			cacheTTL:   -time.Second,
+			want:       false,
+			wantChecks: 2,
+		},
+		{
+			name:     "token revoked here needs no check",
+			cacheTTL: time.Minute,
+			before: func(ctx context.Context, tok TokenInterface) {
+				tok.RevokeAccessToken(ctx, entity.AccessToken{Jti: "jti", UserId: "user", ExpiresAt: now.Add(time.Hour)})
+			},
+			want:       true,
+			wantChecks: 0,
+		},
+		{
+			name:     "user revocation covers older tokens",
+			cacheTTL: time.Minute,
+			before: func(ctx context.Context, tok TokenInterface) {
+				tok.RevokeUser(ctx, "user")
+			},
+			issuedAt:   time.Minute,
+			want:       true,
+			wantChecks: 0,
+		},
+		{
+			name:     "user revocation covers tokens of the same second",
+			cacheTTL: time.Minute,
+			before: func(ctx context.Context, tok TokenInterface) {
+				tok.RevokeUser(ctx, "user")
+			},
+			want:       true,
+			wantChecks: 0,
+		},
+		{
+			name:     "user revocation leaves later tokens to account-service",
+			cacheTTL: time.Minute,
+			before: func(ctx context.Context, tok TokenInterface) {
+				tok.RevokeUser(ctx, "user")
+			},
+			issuedAt:   -2 * time.Second,
+			want:       false,
+			wantChecks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dom := &fakeTokenDomain{revoked: tt.revoked}
+			tok := initToken(&config.Value{Auth: config.Auth{AccessTokenTTL: time.Hour, RevocationCacheTTL: tt.cacheTTL}}, dom)
+
+			if tt.before != nil {
+				tt.before(ctx, tok)
+			}
+
+			accessToken := entity.AccessToken{
+				Jti:       "jti",
+				UserId:    "user",
+				IssuedAt:  time.Unix(now.Add(-tt.issuedAt).Unix(), 0),
+				ExpiresAt: now.Add(time.Hour),
+			}
+
+			for i := 0; i < 2; i++ {
+				revoked, err := tok.IsRevoked(ctx, accessToken)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if revoked != tt.want {
+					t.Fatalf("check %v: got revoked %v, want %v", i+1, revoked, tt.want)
+				}
+			}
+
+			if dom.checks != tt.wantChecks {
+				t.Fatalf("asked account-service %v times, want %v", dom.checks, tt.wantChecks)
+			}
+		})
+	}
+}
@@ -8,11 +8,20 @@ import (
 )
 
 type Usecases struct {
-	User UserInterface
+	User  UserInterface
+	Token TokenInterface
+	Key   KeyInterface
 }
 
-func Init(cfg *config.Value, logger *logrus.Logger, dom *domain.Domains) *Usecases {
-	return &Usecases{
-		User: initUser(cfg, dom.User),
+func Init(cfg *config.Value, logger *logrus.Logger, dom *domain.Domains) (*Usecases, error) {
+	key, err := initKey(cfg, logger)
+	if err != nil {
+		return nil, err
 	}
+
+	return &Usecases{
+		User:  initUser(cfg, dom.User),
+		Token: initToken(cfg, dom.Token),
+		Key:   key,
+	}, nil
 }
@@ -13,11 +13,20 @@ type user struct {
 }
 
 type UserInterface interface {
-	List(ctx context.Context) ([]entity.User, error)
+	List(ctx context.Context, query entity.UserQuery) (entity.UserPage, error)
+	Stream(ctx context.Context, fn func(entity.User) error) error
 	Get(ctx context.Context, filter entity.User) (entity.User, error)
+	BatchGet(ctx context.Context, ids []string) (entity.UserBatchGet, error)
 	Create(ctx context.Context, user entity.User) (entity.User, error)
-	Update(ctx context.Context, user entity.User) (entity.User, error)
+	Import(ctx context.Context, dryRun bool, next func() (entity.UserImportRow, bool, error)) (entity.UserImportReport, error)
+	Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error)
 	Delete(ctx context.Context, user entity.User) error
+	BatchDelete(ctx context.Context, ids []string) (entity.UserBatchDelete, error)
+	Restore(ctx context.Context, user entity.User) (entity.User, error)
+	VerifyCredentials(ctx context.Context, email, password string) (entity.User, error)
+	SetPassword(ctx context.Context, id, password string) error
+	ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error
+	History(ctx context.Context, id string, limit int, cursor string) (entity.UserHistory, error)
 }
 
 // initUser creates user repository
@@ -28,21 +37,57 @@ func initUser(cfg *config.Value, userDom domain.UserInterface) UserInterface {
 	}
 }
 
-func (u *user) List(ctx context.Context) ([]entity.User, error) {
-	return u.user.List(ctx)
+func (u *user) List(ctx context.Context, query entity.UserQuery) (entity.UserPage, error) {
+	return u.user.List(ctx, query)
+}
+
+func (u *user) Stream(ctx context.Context, fn func(entity.User) error) error {
+	return u.user.Stream(ctx, fn)
 }
 
 func (u *user) Get(ctx context.Context, filter entity.User) (entity.User, error) {
 	return u.user.Get(ctx, filter)
 }
 
+func (u *user) BatchGet(ctx context.Context, ids []string) (entity.UserBatchGet, error) {
+	return u.user.BatchGet(ctx, ids)
+}
+
 func (u *user) Create(ctx context.Context, user entity.User) (entity.User, error) {
 	return u.user.Create(ctx, user)
 }
 
-func (u *user) Update(ctx context.Context, user entity.User) (entity.User, error) {
-	return u.user.Update(ctx, user)
+func (u *user) Import(ctx context.Context, dryRun bool, next func() (entity.UserImportRow, bool, error)) (entity.UserImportReport, error) {
+	return u.user.Import(ctx, dryRun, next)
+}
+
+func (u *user) Update(ctx context.Context, user entity.User, fields ...string) (entity.User, error) {
+	return u.user.Update(ctx, user, fields...)
 }
 func (u *user) Delete(ctx context.Context, user entity.User) error {
 	return u.user.Delete(ctx, user)
 }
+
+func (u *user) BatchDelete(ctx context.Context, ids []string) (entity.UserBatchDelete, error) {
+	return u.user.BatchDelete(ctx, ids)
+}
+
+func (u *user) Restore(ctx context.Context, user entity.User) (entity.User, error) {
+	return u.user.Restore(ctx, user)
+}
+
+func (u *user) VerifyCredentials(ctx context.Context, email, password string) (entity.User, error) {
+	return u.user.VerifyCredentials(ctx, email, password)
+}
+
+func (u *user) SetPassword(ctx context.Context, id, password string) error {
+	return u.user.SetPassword(ctx, id, password)
+}
+
+func (u *user) ChangePassword(ctx context.Context, id, oldPassword, newPassword string) error {
+	return u.user.ChangePassword(ctx, id, oldPassword, newPassword)
+}
+
+func (u *user) History(ctx context.Context, id string, limit int, cursor string) (entity.UserHistory, error) {
+	return u.user.History(ctx, id, limit, cursor)
+}